@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// AdminUser is the moderator view of an account.
+type AdminUser struct {
+	User
+	TwoFactorEnabled bool        `json:"two_factor_enabled"`
+	Suspension       *Suspension `json:"suspension"`
+}
+
+func databaseUserToAdminUser(user database.User) AdminUser {
+	return AdminUser{
+		User:             databaseUserToUser(user),
+		TwoFactorEnabled: user.TotpEnabledAt.Valid,
+		Suspension:       userSuspension(user),
+	}
+}
+
+type roleChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// likeEscaper stops a search term's own % and _ acting as wildcards.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// canModerate reports whether caller may suspend or log out target. Nobody
+// acts on their own account, and only admins act on other staff.
+func canModerate(caller auth.Principal, target database.User) bool {
+	if caller.UserID == target.ID {
+		return false
+	}
+	return caller.Role.AtLeast(auth.RoleAdmin) || !auth.Role(target.Role).AtLeast(auth.RoleModerator)
+}
+
+// adminTarget loads the user named by the {id} path value, responding with
+// an error and returning false if there is none.
+func adminTarget(w http.ResponseWriter, r *http.Request, q *database.Queries) (database.User, bool) {
+	targetId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return database.User{}, false
+	}
+	target, err := q.GetUserByID(r.Context(), targetId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get user", err)
+		return database.User{}, false
+	}
+	return target, true
+}
+
+// handleAdminUsersList pages through every account, newest first. The q
+// parameter matches part of an email or username.
+func (cfg *apiConfig) handleAdminUsersList(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Users      []AdminUser `json:"users"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	var search sql.NullString
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		search = sql.NullString{String: likeEscaper.Replace(q), Valid: true}
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetUsersPage(r.Context(), database.GetUsersPageParams{
+		Search:          search,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get users", err)
+		return
+	}
+	res := resBody{Users: []AdminUser{}}
+	if len(data) > int(page.Limit) {
+		data = data[:page.Limit]
+		last := data[len(data)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+	for _, user := range data {
+		res.Users = append(res.Users, databaseUserToAdminUser(user))
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (cfg *apiConfig) handleAdminUserGet(w http.ResponseWriter, r *http.Request) {
+	target, ok := adminTarget(w, r, cfg.dbQueries)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(target))
+}
+
+func (cfg *apiConfig) handleAdminUserRole(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Role string `json:"role"`
+	}
+	caller := currentUser(r)
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	role, ok := auth.ParseRole(request.Role)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Role must be user, moderator or admin", nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to change role", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, ok := adminTarget(w, r, qtx)
+	if !ok {
+		return
+	}
+	if target.ID == caller.UserID && role != auth.RoleAdmin {
+		respondWithError(w, http.StatusBadRequest, "Admins cannot remove their own admin role", nil)
+		return
+	}
+	updated, err := qtx.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+		Role: string(role),
+		ID:   target.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to change role", err)
+		return
+	}
+	err = recordAudit(r.Context(), qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: target.ID, Valid: true},
+		auditRoleChanged, roleChange{From: target.Role, To: updated.Role})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to change role", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to change role", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(updated))
+}
+
+// handleAdminUserSuspend suspends an account until expires_at, or until it
+// is lifted if that is omitted.
+func (cfg *apiConfig) handleAdminUserSuspend(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	caller := currentUser(r)
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	reason, expiresAt, err := parseSuspension(request.Reason, request.ExpiresAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to suspend user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, ok := adminTarget(w, r, qtx)
+	if !ok {
+		return
+	}
+	if !canModerate(caller, target) {
+		respondWithError(w, http.StatusForbidden, "You cannot suspend this user", nil)
+		return
+	}
+	updated, err := suspendUser(r.Context(), qtx, caller, target, reason, expiresAt)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to suspend user", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to suspend user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(updated))
+}
+
+func (cfg *apiConfig) handleAdminUserUnsuspend(w http.ResponseWriter, r *http.Request) {
+	caller := currentUser(r)
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unsuspend user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, ok := adminTarget(w, r, qtx)
+	if !ok {
+		return
+	}
+	if !isSuspended(target) {
+		respondWithError(w, http.StatusConflict, "User is not suspended", nil)
+		return
+	}
+	if !canModerate(caller, target) {
+		respondWithError(w, http.StatusForbidden, "You cannot unsuspend this user", nil)
+		return
+	}
+	updated, err := qtx.UnsuspendUser(r.Context(), target.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unsuspend user", err)
+		return
+	}
+	err = recordAudit(r.Context(), qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: target.ID, Valid: true},
+		auditUserUnsuspended, struct{}{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unsuspend user", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unsuspend user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(updated))
+}
+
+// handleAdminUserLogout revokes every refresh token and personal access
+// token the user holds. Access tokens already issued stay valid until they
+// expire.
+func (cfg *apiConfig) handleAdminUserLogout(w http.ResponseWriter, r *http.Request) {
+	caller := currentUser(r)
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log out user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, ok := adminTarget(w, r, qtx)
+	if !ok {
+		return
+	}
+	if !canModerate(caller, target) {
+		respondWithError(w, http.StatusForbidden, "You cannot log out this user", nil)
+		return
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), target.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log out user", err)
+		return
+	}
+	if err := qtx.RevokeAllPersonalAccessTokensForUser(r.Context(), target.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log out user", err)
+		return
+	}
+	err = recordAudit(r.Context(), qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: target.ID, Valid: true},
+		auditUserLoggedOut, struct{}{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log out user", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log out user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleAdminChirpyRedGrant(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpyRed(w, r, true)
+}
+
+func (cfg *apiConfig) handleAdminChirpyRedRevoke(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpyRed(w, r, false)
+}
+
+// setChirpyRed changes Chirpy Red by hand, for refunds and comped accounts
+// that never pass through the Polka webhook.
+func (cfg *apiConfig) setChirpyRed(w http.ResponseWriter, r *http.Request, isChirpyRed bool) {
+	caller := currentUser(r)
+	action := auditChirpyRedGranted
+	if !isChirpyRed {
+		action = auditChirpyRedRevoked
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update Chirpy Red", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	target, ok := adminTarget(w, r, qtx)
+	if !ok {
+		return
+	}
+	if target.IsChirpyRed == isChirpyRed {
+		respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(target))
+		return
+	}
+	err = qtx.UpdateUserToChirpyRed(r.Context(), database.UpdateUserToChirpyRedParams{
+		IsChirpyRed: isChirpyRed,
+		ID:          target.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update Chirpy Red", err)
+		return
+	}
+	err = recordAudit(r.Context(), qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: target.ID, Valid: true},
+		action, struct{}{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update Chirpy Red", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update Chirpy Red", err)
+		return
+	}
+
+	target.IsChirpyRed = isChirpyRed
+	respondWithJSON(w, http.StatusOK, databaseUserToAdminUser(target))
+}
+
+// bootstrapAdmin promotes an existing user to admin. It is how the first
+// admin is created, since only admins can change roles through the API.
+func bootstrapAdmin(ctx context.Context, db *sql.DB, dbQueries *database.Queries, email string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := dbQueries.WithTx(tx)
+
+	user, err := qtx.GetUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	updated, err := qtx.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+		Role: string(auth.RoleAdmin),
+		ID:   user.ID,
+	})
+	if err != nil {
+		return err
+	}
+	err = recordAudit(ctx, qtx, uuid.NullUUID{}, uuid.NullUUID{UUID: user.ID, Valid: true},
+		auditRoleBootstrap, roleChange{From: user.Role, To: updated.Role})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
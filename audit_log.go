@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Audit log actions.
+const (
+	auditRoleChanged      = "user.role_changed"
+	auditRoleBootstrap    = "user.role_bootstrapped"
+	auditUserSuspended    = "user.suspended"
+	auditUserUnsuspended  = "user.unsuspended"
+	auditUserLoggedOut    = "user.logged_out"
+	auditChirpyRedGranted = "user.chirpy_red_granted"
+	auditChirpyRedRevoked = "user.chirpy_red_revoked"
+	auditReportResolved   = "report.resolved"
+
+	auditFilterRuleCreated = "filter_rule.created"
+	auditFilterRuleUpdated = "filter_rule.updated"
+	auditFilterRuleDeleted = "filter_rule.deleted"
+)
+
+type AuditLogEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      *uuid.UUID      `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID *uuid.UUID      `json:"target_user_id"`
+	Details      json.RawMessage `json:"details"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func databaseAuditLogToAuditLogEntry(entry database.AuditLog) AuditLogEntry {
+	res := AuditLogEntry{
+		ID:        entry.ID,
+		Action:    entry.Action,
+		Details:   entry.Details,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.ActorID.Valid {
+		res.ActorID = &entry.ActorID.UUID
+	}
+	if entry.TargetUserID.Valid {
+		res.TargetUserID = &entry.TargetUserID.UUID
+	}
+	return res
+}
+
+// recordAudit appends an entry to the audit log. Pass the transaction's
+// queries so the entry is only kept if the change it describes is.
+func recordAudit(ctx context.Context, q *database.Queries, actorId, targetUserId uuid.NullUUID, action string, details any) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return q.CreateAuditLogEntry(ctx, database.CreateAuditLogEntryParams{
+		ActorID:      actorId,
+		Action:       action,
+		TargetUserID: targetUserId,
+		Details:      data,
+	})
+}
+
+func (cfg *apiConfig) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Entries    []AuditLogEntry `json:"entries"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetAuditLogPage(r.Context(), database.GetAuditLogPageParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get audit log", err)
+		return
+	}
+	res := resBody{Entries: []AuditLogEntry{}}
+	if len(data) > int(page.Limit) {
+		data = data[:page.Limit]
+		last := data[len(data)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+	for _, entry := range data {
+		res.Entries = append(res.Entries, databaseAuditLogToAuditLogEntry(entry))
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
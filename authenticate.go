@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+// authenticate reads the bearer token, which is either a JWT access token or
+// a personal access token, and loads the principal it stands for.
+func (cfg *apiConfig) authenticate(r *http.Request) (auth.Principal, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	p := auth.Principal{Kind: auth.TokenKindSession}
+	if strings.HasPrefix(token, auth.PersonalAccessTokenPrefix) {
+		pat, err := cfg.dbQueries.GetPersonalAccessTokenByHash(r.Context(), auth.HashToken(token))
+		if err != nil {
+			return auth.Principal{}, errors.New("invalid personal access token")
+		}
+		// last_used_at is only written once a minute per token.
+		if err := cfg.dbQueries.TouchPersonalAccessToken(r.Context(), pat.ID); err != nil {
+			log.Printf("Unable to update token last use: %s", err)
+		}
+		p.UserID = pat.UserID
+		p.Kind = auth.TokenKindPersonal
+		p.TokenID = uuid.NullUUID{UUID: pat.ID, Valid: true}
+		p.Scopes = pat.Scopes
+	} else {
+		p.UserID, err = auth.ValidateJWT(token, cfg.keyring)
+		if err != nil {
+			return auth.Principal{}, err
+		}
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), p.UserID)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if isSuspended(user) {
+		return auth.Principal{}, errAccountSuspended
+	}
+	p.Tier = auth.TierFree
+	if user.IsChirpyRed {
+		p.Tier = auth.TierChirpyRed
+	}
+	p.Role = auth.Role(user.Role)
+	return p, nil
+}
+
+// respondAuthError reports why authenticate refused a request. A suspended
+// user gets 403 so clients don't try to refresh and retry.
+func respondAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errAccountSuspended) {
+		respondWithError(w, http.StatusForbidden, "Account is suspended", err)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Unauthorized: Unable to validate token", err)
+}
+
+// RequireAuth only calls next for an authenticated request whose token
+// allows scope, and makes the principal available through currentUser.
+func (cfg *apiConfig) RequireAuth(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := cfg.authenticate(r)
+		if err != nil {
+			respondAuthError(w, err)
+			return
+		}
+		if !p.HasScope(scope) {
+			respondWithError(w, http.StatusForbidden, "Token is missing the "+scope+" scope", nil)
+			return
+		}
+		next(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	}
+}
+
+// RequireLogin is RequireAuth for account management endpoints, which need a
+// login session and never accept a personal access token.
+func (cfg *apiConfig) RequireLogin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := cfg.authenticate(r)
+		if err != nil {
+			respondAuthError(w, err)
+			return
+		}
+		if p.IsPersonalToken() {
+			respondWithError(w, http.StatusForbidden, "Personal access tokens cannot be used here", nil)
+			return
+		}
+		next(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	}
+}
+
+// RequireRole only calls next for a login session whose user has at least
+// role. It reuses a principal already stored by an outer RequireRole.
+func (cfg *apiConfig) RequireRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			var err error
+			p, err = cfg.authenticate(r)
+			if err != nil {
+				respondAuthError(w, err)
+				return
+			}
+		}
+		if p.IsPersonalToken() || !p.Role.AtLeast(role) {
+			respondWithError(w, http.StatusForbidden, "Requires the "+string(role)+" role", nil)
+			return
+		}
+		next(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	}
+}
+
+// OptionalAuth stores the principal when the request carries a valid token
+// that may read chirps, and otherwise serves it anonymously.
+func (cfg *apiConfig) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p, err := cfg.authenticate(r); err == nil && p.HasScope(auth.ScopeChirpsRead) {
+			r = r.WithContext(auth.NewContext(r.Context(), p))
+		}
+		next(w, r)
+	}
+}
+
+// currentUser returns the principal stored by RequireAuth or RequireLogin.
+// Calling it from a route registered without either is a wiring bug, so it
+// panics rather than acting for the nil user.
+func currentUser(r *http.Request) auth.Principal {
+	p, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		panic("currentUser called on a route without RequireAuth")
+	}
+	return p
+}
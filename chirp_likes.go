@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type ChirpLike struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+func (cfg *apiConfig) handleChirpLike(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	chirpId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to like chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpsByID(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if !cfg.chirpVisible(r, chirp) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	// Liking twice is a no-op; a trigger keeps like_count in step with the rows.
+	_, err = qtx.CreateChirpLike(r.Context(), database.CreateChirpLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to like chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to like chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleChirpUnlike(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	chirpId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpsByID(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if !cfg.chirpVisible(r, chirp) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteChirpLike(r.Context(), database.DeleteChirpLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unlike chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "You have not liked this chirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleChirpLikesList(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Likes      []ChirpLike `json:"likes"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}
+	chirpId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirpsByID(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if !cfg.chirpVisible(r, chirp) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetChirpLikes(r.Context(), database.GetChirpLikesParams{
+		ChirpID:         chirpId,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get likes", err)
+		return
+	}
+	res := resBody{
+		Likes: []ChirpLike{},
+	}
+	if len(data) > int(page.Limit) {
+		data = data[:page.Limit]
+		last := data[len(data)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.UserID}.encode()
+	}
+	for _, val := range data {
+		res.Likes = append(res.Likes, ChirpLike{
+			UserID:  val.UserID,
+			LikedAt: val.CreatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
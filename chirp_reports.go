@@ -0,0 +1,310 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxReportDetailsLength = 500
+
+// reportCategories are the categories users report under. The CHECK
+// constraint on chirp_reports.category also allows reportCategoryContentFilter.
+var reportCategories = map[string]struct{}{
+	"spam":           {},
+	"harassment":     {},
+	"hate":           {},
+	"violence":       {},
+	"sexual":         {},
+	"misinformation": {},
+	"other":          {},
+}
+
+// Ways a moderator can resolve a report. They are stored as the resolution.
+const (
+	reportActionDismiss       = "dismiss"
+	reportActionHideChirp     = "hide_chirp"
+	reportActionDeleteChirp   = "delete_chirp"
+	reportActionSuspendAuthor = "suspend_author"
+)
+
+type ChirpReport struct {
+	ID         uuid.UUID  `json:"id"`
+	ChirpID    *uuid.UUID `json:"chirp_id"`
+	AuthorID   uuid.UUID  `json:"author_id"`
+	ReporterID *uuid.UUID `json:"reporter_id"`
+	Category   string     `json:"category"`
+	Details    string     `json:"details"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Chirp is only filled in for the moderation queue, and stays null once
+	// the chirp has been deleted.
+	Chirp *Chirp `json:"chirp,omitempty"`
+}
+
+func databaseReportToChirpReport(report database.ChirpReport) ChirpReport {
+	res := ChirpReport{
+		ID:        report.ID,
+		AuthorID:  report.AuthorID,
+		Category:  report.Category,
+		Details:   report.Details,
+		CreatedAt: report.CreatedAt,
+	}
+	if report.ChirpID.Valid {
+		res.ChirpID = &report.ChirpID.UUID
+	}
+	if report.ReporterID.Valid {
+		res.ReporterID = &report.ReporterID.UUID
+	}
+	return res
+}
+
+func (cfg *apiConfig) handleChirpReport(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Category string `json:"category"`
+		Details  string `json:"details"`
+	}
+	userId := currentUser(r).UserID
+	chirpId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	if _, ok := reportCategories[request.Category]; !ok {
+		respondWithError(w, http.StatusBadRequest, "Unknown report category", nil)
+		return
+	}
+	details := strings.TrimSpace(request.Details)
+	if len(details) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "Details are too long", nil)
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpsByID(r.Context(), chirpId)
+	if err != nil || !cfg.chirpVisible(r, chirp) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if chirp.UserID == userId {
+		respondWithError(w, http.StatusBadRequest, "You cannot report your own chirp", nil)
+		return
+	}
+	report, err := cfg.dbQueries.CreateChirpReport(r.Context(), database.CreateChirpReportParams{
+		ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		AuthorID:   chirp.UserID,
+		ReporterID: uuid.NullUUID{UUID: userId, Valid: true},
+		Category:   request.Category,
+		Details:    details,
+	})
+	if isUniqueViolation(err, "chirp_reports_open_reporter_idx") {
+		respondWithError(w, http.StatusConflict, "You have already reported this chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to report chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, databaseReportToChirpReport(report))
+}
+
+// handleReportQueue lists open reports, oldest first, with the chirp each is
+// about as moderators see it.
+func (cfg *apiConfig) handleReportQueue(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Reports    []ChirpReport `json:"reports"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetOpenChirpReportsPage(r.Context(), database.GetOpenChirpReportsPageParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get reports", err)
+		return
+	}
+	res := resBody{Reports: []ChirpReport{}}
+	if len(data) > int(page.Limit) {
+		data = data[:page.Limit]
+		last := data[len(data)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	chirpIds := []uuid.UUID{}
+	for _, report := range data {
+		if report.ChirpID.Valid {
+			chirpIds = append(chirpIds, report.ChirpID.UUID)
+		}
+	}
+	chirpsById := map[uuid.UUID]*Chirp{}
+	if len(chirpIds) > 0 {
+		found, err := cfg.dbQueries.GetChirpsByIDs(r.Context(), chirpIds)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to get reports", err)
+			return
+		}
+		chirps, err := cfg.renderChirps(r.Context(), uuid.NullUUID{}, true, found)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to get reports", err)
+			return
+		}
+		for i := range chirps {
+			chirpsById[chirps[i].ID] = &chirps[i]
+		}
+	}
+	for _, report := range data {
+		res.Reports = append(res.Reports, databaseReportToChirpReport(report))
+		res.Reports[len(res.Reports)-1].Chirp = chirpsById[report.ChirpID.UUID]
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// handleReportResolve closes a report, and every other open report on the
+// same chirp, with one of the report actions.
+func (cfg *apiConfig) handleReportResolve(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Action string `json:"action"`
+		// Reason and ExpiresAt describe the suspension for suspend_author.
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	type resBody struct {
+		Action   string `json:"action"`
+		Resolved int64  `json:"resolved_reports"`
+	}
+	type auditDetails struct {
+		ReportID uuid.UUID  `json:"report_id"`
+		ChirpID  *uuid.UUID `json:"chirp_id"`
+		Action   string     `json:"action"`
+		Resolved int64      `json:"resolved_reports"`
+	}
+	caller := currentUser(r)
+	reportId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	var reason string
+	var expiresAt sql.NullTime
+	switch request.Action {
+	case reportActionDismiss, reportActionHideChirp, reportActionDeleteChirp:
+	case reportActionSuspendAuthor:
+		reason, expiresAt, err = parseSuspension(request.Reason, request.ExpiresAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "Action must be dismiss, hide_chirp, delete_chirp or suspend_author", nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	report, err := qtx.GetChirpReportForUpdate(r.Context(), reportId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Report not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+	if report.ResolvedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Report is already resolved", nil)
+		return
+	}
+	if !report.ChirpID.Valid && (request.Action == reportActionHideChirp || request.Action == reportActionDeleteChirp) {
+		respondWithError(w, http.StatusConflict, "Chirp has already been deleted", nil)
+		return
+	}
+
+	// Reports are resolved before the action, since deleting the chirp
+	// clears their chirp_id.
+	resolved, err := qtx.ResolveChirpReports(r.Context(), database.ResolveChirpReportsParams{
+		ResolvedBy: uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		Resolution: sql.NullString{String: request.Action, Valid: true},
+		ID:         report.ID,
+		ChirpID:    report.ChirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+
+	switch request.Action {
+	case reportActionHideChirp:
+		_, err = qtx.HideChirp(r.Context(), report.ChirpID.UUID)
+	case reportActionDeleteChirp:
+		var chirp database.Chirp
+		chirp, err = qtx.GetChirpByIDForUpdate(r.Context(), report.ChirpID.UUID)
+		if err == nil {
+			err = deleteChirp(r.Context(), qtx, chirp)
+		}
+	case reportActionSuspendAuthor:
+		var author database.User
+		author, err = qtx.GetUserByID(r.Context(), report.AuthorID)
+		if err != nil {
+			break
+		}
+		if !canModerate(caller, author) {
+			respondWithError(w, http.StatusForbidden, "You cannot suspend this user", nil)
+			return
+		}
+		_, err = suspendUser(r.Context(), qtx, caller, author, reason, expiresAt)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+
+	details := auditDetails{
+		ReportID: report.ID,
+		Action:   request.Action,
+		Resolved: resolved,
+	}
+	if report.ChirpID.Valid {
+		details.ChirpID = &report.ChirpID.UUID
+	}
+	err = recordAudit(r.Context(), qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: report.AuthorID, Valid: true},
+		auditReportResolved, details)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to resolve report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody{Action: request.Action, Resolved: resolved})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	parsedId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirpsByID(r.Context(), parsedId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if !cfg.chirpVisible(r, chirp) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	data, err := cfg.dbQueries.GetChirpRevisions(r.Context(), parsedId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp revisions", err)
+		return
+	}
+	revisions := []ChirpRevision{}
+	for _, val := range data {
+		revisions = append(revisions, ChirpRevision{
+			ID:        val.ID,
+			ChirpID:   val.ChirpID,
+			Body:      val.Body,
+			CreatedAt: val.CreatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, revisions)
+}
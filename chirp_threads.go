@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// ChirpThreadNode is a chirp in a thread with its replies. A chirp the
+// caller may not see keeps its place as a placeholder with only Unavailable
+// set, so the replies under it still show.
+type ChirpThreadNode struct {
+	*Chirp
+	Unavailable bool               `json:"unavailable,omitempty"`
+	Replies     []*ChirpThreadNode `json:"replies"`
+}
+
+// handleChirpThread returns the whole conversation the chirp belongs to,
+// starting from the root of the thread.
+func (cfg *apiConfig) handleChirpThread(w http.ResponseWriter, r *http.Request) {
+	parsedId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	data, err := cfg.dbQueries.GetChirpThread(r.Context(), parsedId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get thread", err)
+		return
+	}
+	threadChirps := make([]database.Chirp, 0, len(data))
+	for _, val := range data {
+		if cfg.chirpVisible(r, val.Chirp) {
+			threadChirps = append(threadChirps, val.Chirp)
+		}
+	}
+	rendered, err := cfg.renderChirps(r.Context(), cfg.viewerID(r), canSeeHiddenChirps(r), threadChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get thread", err)
+		return
+	}
+	chirps := make(map[uuid.UUID]*Chirp, len(rendered))
+	for i := range rendered {
+		chirps[rendered[i].ID] = &rendered[i]
+	}
+	// The rest of the thread may be partly hidden, but the chirp asked for
+	// must be visible.
+	if _, ok := chirps[parsedId]; !ok {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+
+	// Rows come back ordered by depth, so every parent is seen before its replies.
+	nodes := make(map[uuid.UUID]*ChirpThreadNode, len(data))
+	var root *ChirpThreadNode
+	for _, val := range data {
+		node := &ChirpThreadNode{
+			Replies: []*ChirpThreadNode{},
+		}
+		if chirp, ok := chirps[val.Chirp.ID]; ok {
+			node.Chirp = chirp
+		} else {
+			node.Unavailable = true
+		}
+		nodes[val.Chirp.ID] = node
+		if val.Depth == 0 {
+			root = node
+			continue
+		}
+		if parent, ok := nodes[val.Chirp.InReplyTo.UUID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+	if root == nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, root)
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 
@@ -12,64 +14,218 @@ import (
 	"github.com/google/uuid"
 )
 
-// func handleChirpValidation(w http.ResponseWriter, r *http.Request) {
-// 	w.Header().Add("Content-Type", "application/json")
-
-// 	type parameters struct {
-// 		Body string `json:"body"`
-// 	}
-// 	type resBody struct {
-// 		CleanedBody string `json:"cleaned_body"`
-// 	}
-// 	var val parameters
-// 	decoder := json.NewDecoder(r.Body)
-// 	if err := decoder.Decode(&val); err != nil {
-// 		respondWithError(w, http.StatusBadRequest, "Something went wrong", err)
-// 		return
-// 	}
-
-// 	const maxChirpLength = 140
-// 	if len(val.Body) > maxChirpLength {
-// 		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
-// 		return
-// 	}
-
-// 	cleaned := profaneFilter(val.Body)
-
-// 	respondWithJSON(w, http.StatusOK, resBody{
-// 		CleanedBody: cleaned,
-// 	})
-// }
-
-func profaneFilter(body string) string {
-	list := strings.Split(body, " ")
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
-	}
-
-	for i, val := range list {
-		lowerVal := strings.ToLower(val)
-		if _, ok := badWords[lowerVal]; ok {
-			list[i] = "****"
+const maxChirpLength = 140
+
+var errChirpTooLong = errors.New("Chirp is too long")
+
+type Chirp struct {
+	ID         uuid.UUID    `json:"id"`
+	Body       string       `json:"body"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	UserID     uuid.UUID    `json:"user_id"`
+	InReplyTo  *uuid.UUID   `json:"in_reply_to"`
+	ReplyCount int32        `json:"reply_count"`
+	LikeCount  int32        `json:"like_count"`
+	LikedByMe  *bool        `json:"liked_by_me,omitempty"`
+	Mentions   []Mention    `json:"mentions"`
+	RechirpOf  *Chirp       `json:"rechirp_of,omitempty"`
+	QuoteOf    *QuotedChirp `json:"quote_of,omitempty"`
+	Hidden     bool         `json:"hidden,omitempty"`
+}
+
+// QuotedChirp is the chirp embedded in a quote. Once the original has been
+// deleted the quote keeps a tombstone with only Deleted set.
+type QuotedChirp struct {
+	*Chirp
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+func databaseChirpToChirp(chirp database.Chirp) Chirp {
+	res := Chirp{
+		ID:         chirp.ID,
+		UserID:     chirp.UserID,
+		Body:       chirp.Body,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		ReplyCount: chirp.ReplyCount,
+		LikeCount:  chirp.LikeCount,
+		Mentions:   []Mention{},
+		Hidden:     chirp.HiddenAt.Valid,
+	}
+	if chirp.InReplyTo.Valid {
+		res.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	return res
+}
+
+// renderChirps converts chirps for the API and fills in the fields that
+// depend on who is looking at them. viewerId is null for anonymous callers;
+// includeHidden is set for moderators, who see hidden chirps as they were.
+// Rechirps of a hidden chirp are dropped for everyone else.
+func (cfg *apiConfig) renderChirps(ctx context.Context, viewerId uuid.NullUUID, includeHidden bool, data []database.Chirp) ([]Chirp, error) {
+	embeddedIds := []uuid.UUID{}
+	for _, val := range data {
+		if val.RechirpOf.Valid {
+			embeddedIds = append(embeddedIds, val.RechirpOf.UUID)
+		}
+		if val.QuoteOf.Valid {
+			embeddedIds = append(embeddedIds, val.QuoteOf.UUID)
+		}
+	}
+
+	// Rechirps and quotes carry the chirp they point at, loaded in one query.
+	embedded := map[uuid.UUID]*Chirp{}
+	if len(embeddedIds) > 0 {
+		originals, err := cfg.dbQueries.GetChirpsByIDs(ctx, embeddedIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range originals {
+			// A hidden original is left out as if it were gone, except for its
+			// author and moderators.
+			if val.HiddenAt.Valid && !includeHidden && (!viewerId.Valid || viewerId.UUID != val.UserID) {
+				continue
+			}
+			original := databaseChirpToChirp(val)
+			embedded[val.ID] = &original
+		}
+	}
+	chirps := make([]Chirp, 0, len(data))
+	for _, val := range data {
+		chirp := databaseChirpToChirp(val)
+		if val.RechirpOf.Valid {
+			original, ok := embedded[val.RechirpOf.UUID]
+			if !ok {
+				continue
+			}
+			chirp.RechirpOf = original
+		}
+		if original, ok := embedded[val.QuoteOf.UUID]; val.QuoteOf.Valid && ok {
+			chirp.QuoteOf = &QuotedChirp{Chirp: original}
+		} else if val.QuoteDeleted {
+			chirp.QuoteOf = &QuotedChirp{Deleted: true}
 		}
+		chirps = append(chirps, chirp)
 	}
 
-	return strings.Join(list, " ")
+	if len(chirps) == 0 {
+		return chirps, nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps)+len(embedded))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+	for id := range embedded {
+		ids = append(ids, id)
+	}
+
+	mentions, err := cfg.dbQueries.GetChirpMentions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentionsByChirp := map[uuid.UUID][]Mention{}
+	for _, val := range mentions {
+		mentionsByChirp[val.ChirpID] = append(mentionsByChirp[val.ChirpID], Mention{
+			UserID:   val.UserID,
+			Username: val.Username.String,
+			Start:    val.StartOffset,
+			End:      val.EndOffset,
+		})
+	}
+	for i := range chirps {
+		if found, ok := mentionsByChirp[chirps[i].ID]; ok {
+			chirps[i].Mentions = found
+		}
+	}
+	for id, chirp := range embedded {
+		if found, ok := mentionsByChirp[id]; ok {
+			chirp.Mentions = found
+		}
+	}
+
+	if !viewerId.Valid {
+		return chirps, nil
+	}
+	likedIds, err := cfg.dbQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerId.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIds))
+	for _, id := range likedIds {
+		liked[id] = true
+	}
+	for i := range chirps {
+		likedByMe := liked[chirps[i].ID]
+		chirps[i].LikedByMe = &likedByMe
+	}
+	for id, chirp := range embedded {
+		likedByMe := liked[id]
+		chirp.LikedByMe = &likedByMe
+	}
+	return chirps, nil
 }
 
-type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
+// renderChirp renders a single chirp. It returns sql.ErrNoRows when chirp is
+// a rechirp of a chirp the viewer may not see.
+func (cfg *apiConfig) renderChirp(ctx context.Context, viewerId uuid.NullUUID, includeHidden bool, chirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.renderChirps(ctx, viewerId, includeHidden, []database.Chirp{chirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	if len(chirps) == 0 {
+		return Chirp{}, sql.ErrNoRows
+	}
+	return chirps[0], nil
+}
+
+// viewerID returns the caller's user ID when OptionalAuth accepted the
+// request's token, and a null ID otherwise.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	caller, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: caller.UserID, Valid: true}
+}
+
+// canSeeHiddenChirps reports whether the caller is a moderator signed in
+// with a session, who sees hidden chirps as they were.
+func canSeeHiddenChirps(r *http.Request) bool {
+	caller, ok := auth.UserFromContext(r.Context())
+	return ok && !caller.IsPersonalToken() && caller.Role.AtLeast(auth.RoleModerator)
+}
+
+// chirpVisible reports whether the caller may see chirp. Hidden chirps stay
+// visible to their author and to moderators.
+func (cfg *apiConfig) chirpVisible(r *http.Request, chirp database.Chirp) bool {
+	if !chirp.HiddenAt.Valid || canSeeHiddenChirps(r) {
+		return true
+	}
+	viewerId := cfg.viewerID(r)
+	return viewerId.Valid && viewerId.UUID == chirp.UserID
+}
+
+func parseAuthorID(r *http.Request) (uuid.NullUUID, error) {
+	authorIdString := r.URL.Query().Get("author_id")
+	if authorIdString == "" {
+		return uuid.NullUUID{}, nil
+	}
+	parsedId, err := uuid.Parse(authorIdString)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: parsedId, Valid: true}, nil
 }
 
 func (cfg *apiConfig) handleChirpsCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
 	type resBody struct {
 		Chirp
@@ -81,89 +237,140 @@ func (cfg *apiConfig) handleChirpsCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	const maxChirpLength = 140
-	if len(reqBody.Body) > maxChirpLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	filtered, err := cfg.filterChirpBody(reqBody.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	cleanedBody := profaneFilter(reqBody.Body)
-
-	jwt, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Unable to get bearer token", err)
+	userId := currentUser(r).UserID
+	if !cfg.requireVerifiedEmail(w, r, userId) {
 		return
 	}
 
-	userId, err := auth.ValidateJWT(jwt, cfg.jwtSecret)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Unable to validate JWT", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to create chirp", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	inReplyTo := uuid.NullUUID{}
+	if reqBody.InReplyTo != nil {
+		parent, err := qtx.GetChirpsByID(r.Context(), *reqBody.InReplyTo)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Chirp being replied to does not exist", err)
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+	quoteOf := uuid.NullUUID{}
+	if reqBody.QuoteOf != nil {
+		quoted, err := qtx.GetChirpsByID(r.Context(), *reqBody.QuoteOf)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Chirp being quoted does not exist", err)
+			return
+		}
+		// Quoting a rechirp quotes the chirp it points at.
+		if quoted.RechirpOf.Valid {
+			quoteOf = quoted.RechirpOf
+		} else {
+			quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+		}
+	}
 
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: userId,
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:      filtered.Text,
+		UserID:    userId,
+		InReplyTo: inReplyTo,
+		QuoteOf:   quoteOf,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Unable to create UUID", err)
 		return
 	}
+	if err := indexChirpHashtags(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to store hashtags", err)
+		return
+	}
+	if err := indexChirpMentions(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to store mentions", err)
+		return
+	}
+	if err := flagChirp(r.Context(), qtx, chirp, filtered); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to flag chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create chirp", err)
+		return
+	}
 
+	res, err := cfg.renderChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+		return
+	}
 	respondWithJSON(w, http.StatusCreated, resBody{
-		Chirp: Chirp{
-			ID:        chirp.ID,
-			UserID:    chirp.UserID,
-			Body:      chirp.Body,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-		},
+		Chirp: res,
 	})
 }
 
 func (cfg *apiConfig) handleChirpsRetrieve(w http.ResponseWriter, r *http.Request) {
-	authorId := uuid.Nil
-	authorIdString := r.URL.Query().Get("author_id")
-	var err error
-	if authorIdString != "" {
-		authorId, err = uuid.Parse(authorIdString)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Author ID is not in correct format", err)
-			return
-		}
+	type resBody struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
 	}
-	data, err := cfg.dbQueries.GetAllChirps(r.Context())
+	authorId, err := parseAuthorID(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to get all chirps", err)
+		respondWithError(w, http.StatusBadRequest, "Author ID is not in correct format", err)
 		return
 	}
-	listChirps := []Chirp{}
-	for _, val := range data {
-		if authorId != uuid.Nil && authorId != val.UserID {
-			continue
-		}
-		listChirps = append(listChirps, Chirp{
-			ID:        val.ID,
-			UserID:    val.UserID,
-			Body:      val.Body,
-			CreatedAt: val.CreatedAt,
-			UpdatedAt: val.UpdatedAt,
-		})
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
-	sortDirection := "asc"
-	sortParam := r.URL.Query().Get("sort")
-	if sortParam != "" {
-		sortDirection = strings.ToLower(sortParam)
-		sort.Slice(listChirps, func(i, j int) bool {
-			if sortDirection == "desc" {
-				return listChirps[j].CreatedAt.Before(listChirps[i].CreatedAt)
-			}
-			return listChirps[i].CreatedAt.Before(listChirps[j].CreatedAt)
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	viewerId := cfg.viewerID(r)
+	includeHidden := canSeeHiddenChirps(r)
+
+	// Fetch one extra row so we know whether another page follows.
+	var data []database.Chirp
+	if strings.ToLower(r.URL.Query().Get("sort")) == "desc" {
+		data, err = cfg.dbQueries.GetChirpsPageDesc(r.Context(), database.GetChirpsPageDescParams{
+			AuthorID:        authorId,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorId,
+			IncludeHidden:   includeHidden,
+			ViewerID:        viewerId,
+			PageLimit:       page.Limit + 1,
+		})
+	} else {
+		data, err = cfg.dbQueries.GetChirpsPageAsc(r.Context(), database.GetChirpsPageAscParams{
+			AuthorID:        authorId,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorId,
+			IncludeHidden:   includeHidden,
+			ViewerID:        viewerId,
+			PageLimit:       page.Limit + 1,
 		})
 	}
-	respondWithJSON(w, http.StatusOK,
-		listChirps,
-	)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get all chirps", err)
+		return
+	}
+	data, nextCursor := trimChirpPage(data, page.Limit)
+	chirps, err := cfg.renderChirps(r.Context(), viewerId, includeHidden, data)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get all chirps", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resBody{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
 }
 
 func (cfg *apiConfig) handleChirpsRetrieveByID(w http.ResponseWriter, r *http.Request) {
@@ -185,30 +392,135 @@ func (cfg *apiConfig) handleChirpsRetrieveByID(w http.ResponseWriter, r *http.Re
 		respondWithError(w, http.StatusNotFound, "Unable to get all chirps", err)
 		return
 	}
+	if !cfg.chirpVisible(r, data) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	chirp, err := cfg.renderChirp(r.Context(), cfg.viewerID(r), canSeeHiddenChirps(r), data)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+		return
+	}
 	respondWithJSON(w, http.StatusOK,
 		resBody{
-			Chirp: Chirp{
-				ID:        data.ID,
-				UserID:    data.UserID,
-				Body:      data.Body,
-				CreatedAt: data.CreatedAt,
-				UpdatedAt: data.UpdatedAt,
-			},
+			Chirp: chirp,
 		},
 	)
 }
 
-func (cfg *apiConfig) HandleChirpsDeleteByID(w http.ResponseWriter, r *http.Request) {
-	jwt, err := auth.GetBearerToken(r.Header)
+func (cfg *apiConfig) handleChirpsUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	type resBody struct {
+		Chirp
+	}
+	userId := currentUser(r).UserID
+	parsedId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	var reqBody parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Something went wrong", err)
+		return
+	}
+	filtered, err := cfg.filterChirpBody(reqBody.Body)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Unable to get bearer token", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	userId, err := auth.ValidateJWT(jwt, cfg.jwtSecret)
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Unable to validate JWT", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to update chirp", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), parsedId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+		return
+	}
+	if chirp.UserID != userId {
+		respondWithError(w, http.StatusForbidden, "Unauthorized: You are not the owner of this chirp", nil)
+		return
+	}
+	if chirp.RechirpOf.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited", nil)
+		return
+	}
+	if chirp.Body == filtered.Text {
+		res, err := cfg.renderChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), chirp)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, resBody{
+			Chirp: res,
+		})
+		return
+	}
+
+	// The revision keeps the body being replaced, stamped with the time it was written.
+	_, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to store chirp revision", err)
+		return
+	}
+	updatedChirp, err := qtx.UpdateChirp(r.Context(), database.UpdateChirpParams{
+		Body: filtered.Text,
+		ID:   chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update chirp", err)
+		return
+	}
+	if err := indexChirpHashtags(r.Context(), qtx, updatedChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to store hashtags", err)
+		return
+	}
+	if err := indexChirpMentions(r.Context(), qtx, updatedChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to store mentions", err)
+		return
+	}
+	if err := flagChirp(r.Context(), qtx, updatedChirp, filtered); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to flag chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update chirp", err)
+		return
+	}
+
+	res, err := cfg.renderChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), updatedChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resBody{
+		Chirp: res,
+	})
+}
+
+func (cfg *apiConfig) HandleChirpsDeleteByID(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
 	id := r.PathValue("id")
 	if id == "" {
 		respondWithError(w, http.StatusBadRequest, "Id is required", nil)
@@ -219,7 +531,15 @@ func (cfg *apiConfig) HandleChirpsDeleteByID(w http.ResponseWriter, r *http.Requ
 		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
 		return
 	}
-	chirp, err := cfg.dbQueries.GetChirpsByID(r.Context(), parsedId)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), parsedId)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
 		return
@@ -228,10 +548,20 @@ func (cfg *apiConfig) HandleChirpsDeleteByID(w http.ResponseWriter, r *http.Requ
 		respondWithError(w, http.StatusForbidden, "Unauthorized: You are not the owner of this chirp", nil)
 		return
 	}
-	err = cfg.dbQueries.DeleteChirpsByID(r.Context(), parsedId)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Unable to found the chirp", err)
+	if err := deleteChirp(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete chirp", err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// deleteChirp removes chirp. The chirp should be locked with
+// GetChirpByIDForUpdate first. Triggers lower its parent's reply_count and
+// leave a tombstone on quotes of it.
+func deleteChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	return qtx.DeleteChirpsByID(ctx, chirp.ID)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleChirpsSearch(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Search query is required", nil)
+		return
+	}
+	authorId, err := parseAuthorID(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Author ID is not in correct format", err)
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	_, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:      query,
+		AuthorID:   authorId,
+		CursorRank: page.rankArg(),
+		CursorID:   cursorId,
+		PageLimit:  page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to search chirps", err)
+		return
+	}
+	res := resBody{}
+	if len(data) > int(page.Limit) {
+		data = data[:page.Limit]
+		last := data[len(data)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.Chirp.CreatedAt, ID: last.Chirp.ID, Rank: last.Rank}.encode()
+	}
+	chirps := make([]database.Chirp, 0, len(data))
+	for _, val := range data {
+		chirps = append(chirps, val.Chirp)
+	}
+	res.Chirps, err = cfg.renderChirps(r.Context(), cfg.viewerID(r), canSeeHiddenChirps(r), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to search chirps", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
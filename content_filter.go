@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+// contentFilterReloadInterval is how often rules are reloaded, so a change
+// made through another server reaches this one too.
+const contentFilterReloadInterval = 30 * time.Second
+
+// reportCategoryContentFilter files chirps a flag rule matched in the
+// moderation queue. Users can't pick it.
+const reportCategoryContentFilter = "content_filter"
+
+var errChirpRejected = errors.New("Chirp contains content that is not allowed")
+
+type ContentFilterRule struct {
+	ID        uuid.UUID `json:"id"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func databaseRuleToContentFilterRule(rule database.ContentFilterRule) ContentFilterRule {
+	return ContentFilterRule{
+		ID:        rule.ID,
+		Kind:      rule.Kind,
+		Pattern:   rule.Pattern,
+		Action:    rule.Action,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+	}
+}
+
+// reloadContentFilter compiles the stored rules and swaps them in. Chirps
+// being checked meanwhile finish with the rules they started with.
+func (cfg *apiConfig) reloadContentFilter(ctx context.Context) error {
+	data, err := cfg.dbQueries.ListContentFilterRules(ctx)
+	if err != nil {
+		return err
+	}
+	rules := make([]moderation.Rule, 0, len(data))
+	for _, rule := range data {
+		rules = append(rules, moderation.Rule{
+			ID:      rule.ID,
+			Kind:    moderation.Kind(rule.Kind),
+			Pattern: rule.Pattern,
+			Action:  moderation.Action(rule.Action),
+		})
+	}
+	pipeline, err := moderation.Compile(rules)
+	if err != nil {
+		return err
+	}
+	cfg.contentFilter.Store(pipeline)
+	return nil
+}
+
+// watchContentFilter reloads the rules every interval. A failed reload
+// keeps the rules already loaded.
+func (cfg *apiConfig) watchContentFilter(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := cfg.reloadContentFilter(context.Background()); err != nil {
+			log.Printf("Unable to reload content filter: %s", err)
+		}
+	}
+}
+
+// filterChirpBody runs body through the content filter. The result's Text is
+// the body as it should be saved.
+func (cfg *apiConfig) filterChirpBody(body string) (moderation.Result, error) {
+	if utf8.RuneCountInString(body) > maxChirpLength {
+		return moderation.Result{}, errChirpTooLong
+	}
+	res := cfg.contentFilter.Load().Apply(body)
+	if res.Rejected() {
+		return moderation.Result{}, errChirpRejected
+	}
+	// Masking can lengthen the body, so the saved text is checked too.
+	if utf8.RuneCountInString(res.Text) > maxChirpLength {
+		return moderation.Result{}, errChirpTooLong
+	}
+	return res, nil
+}
+
+// flagChirp files chirp in the moderation queue when a flag rule matched it,
+// unless it already has an open content filter report.
+func flagChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp, res moderation.Result) error {
+	if !res.Flagged() {
+		return nil
+	}
+	var ruleIds []string
+	for _, m := range res.Matches {
+		if m.Action == moderation.ActionFlag {
+			ruleIds = append(ruleIds, m.RuleID.String())
+		}
+	}
+	return qtx.CreateContentFilterReport(ctx, database.CreateContentFilterReportParams{
+		ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		AuthorID: chirp.UserID,
+		Category: reportCategoryContentFilter,
+		Details:  "Matched content filter rules " + strings.Join(ruleIds, ", "),
+	})
+}
+
+func (cfg *apiConfig) handleFilterRulesList(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Rules []ContentFilterRule `json:"rules"`
+	}
+	data, err := cfg.dbQueries.ListContentFilterRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get rules", err)
+		return
+	}
+	res := resBody{Rules: []ContentFilterRule{}}
+	for _, rule := range data {
+		res.Rules = append(res.Rules, databaseRuleToContentFilterRule(rule))
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (cfg *apiConfig) handleFilterRuleCreate(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Kind    string `json:"kind"`
+		Pattern string `json:"pattern"`
+		Action  string `json:"action"`
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	rule, err := moderation.ParseRule(request.Kind, request.Pattern, request.Action)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cfg.changeFilterRule(w, r, http.StatusCreated, auditFilterRuleCreated, func(qtx *database.Queries) (database.ContentFilterRule, error) {
+		return qtx.CreateContentFilterRule(r.Context(), database.CreateContentFilterRuleParams{
+			Kind:    string(rule.Kind),
+			Pattern: rule.Pattern,
+			Action:  string(rule.Action),
+		})
+	})
+}
+
+// handleFilterRuleUpdate replaces a rule's pattern and action. Its kind
+// can't change.
+func (cfg *apiConfig) handleFilterRuleUpdate(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Pattern string `json:"pattern"`
+		Action  string `json:"action"`
+	}
+	ruleId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	existing, err := cfg.dbQueries.GetContentFilterRule(r.Context(), ruleId)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Rule not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get rule", err)
+		return
+	}
+	rule, err := moderation.ParseRule(existing.Kind, request.Pattern, request.Action)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cfg.changeFilterRule(w, r, http.StatusOK, auditFilterRuleUpdated, func(qtx *database.Queries) (database.ContentFilterRule, error) {
+		return qtx.UpdateContentFilterRule(r.Context(), database.UpdateContentFilterRuleParams{
+			Pattern: rule.Pattern,
+			Action:  string(rule.Action),
+			ID:      ruleId,
+		})
+	})
+}
+
+func (cfg *apiConfig) handleFilterRuleDelete(w http.ResponseWriter, r *http.Request) {
+	ruleId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	cfg.changeFilterRule(w, r, http.StatusOK, auditFilterRuleDeleted, func(qtx *database.Queries) (database.ContentFilterRule, error) {
+		return qtx.DeleteContentFilterRule(r.Context(), ruleId)
+	})
+}
+
+// changeFilterRule runs change and records it in the audit log in one
+// transaction, then reloads the filter so the change applies at once.
+func (cfg *apiConfig) changeFilterRule(w http.ResponseWriter, r *http.Request, status int, action string, change func(qtx *database.Queries) (database.ContentFilterRule, error)) {
+	caller := currentUser(r)
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to save rule", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	rule, err := change(qtx)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Rule not found", err)
+		return
+	}
+	if isUniqueViolation(err, "content_filter_rules_kind_pattern_key") {
+		respondWithError(w, http.StatusConflict, "A rule with this pattern already exists", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to save rule", err)
+		return
+	}
+	res := databaseRuleToContentFilterRule(rule)
+	err = recordAudit(r.Context(), qtx, uuid.NullUUID{UUID: caller.UserID, Valid: true}, uuid.NullUUID{}, action, res)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to save rule", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to save rule", err)
+		return
+	}
+
+	if err := cfg.reloadContentFilter(r.Context()); err != nil {
+		log.Printf("Unable to reload content filter: %s", err)
+	}
+	respondWithJSON(w, status, res)
+}
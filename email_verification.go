@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const emailVerificationTokenTTL = 24 * time.Hour
+
+// emailVerificationSendTimeout bounds mailing a verification token, which
+// happens while the request that issued it waits.
+const emailVerificationSendTimeout = 10 * time.Second
+
+// validEmail accepts a bare address such as "user@example.com", without a
+// display name or angle brackets.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// issueEmailVerificationToken replaces any pending token for the user with a
+// new one for email. The email only becomes the account's address once the
+// token is used.
+func issueEmailVerificationToken(ctx context.Context, q *database.Queries, userId uuid.UUID, email string) (string, error) {
+	if err := q.DeleteUnusedEmailVerificationTokens(ctx, userId); err != nil {
+		return "", err
+	}
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = q.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userId,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendEmailVerification mails the token. Failures are only logged; the user
+// can ask for a new token through POST /api/users/verify/resend.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, email, token string) {
+	ctx, cancel := context.WithTimeout(ctx, emailVerificationSendTimeout)
+	defer cancel()
+	err := cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf("Confirm this address for your Chirpy account with the token: %s\n\n"+
+			"It expires in %s.\n", token, emailVerificationTokenTTL),
+	})
+	if err != nil {
+		log.Printf("Unable to send verification email: %s", err)
+	}
+}
+
+// requireVerifiedEmail responds with 403 and returns false if the user has
+// not verified their email address yet.
+func (cfg *apiConfig) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, userId uuid.UUID) bool {
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Verify your email address first", nil)
+		return false
+	}
+	return true
+}
+
+func (cfg *apiConfig) handleEmailVerify(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Token string `json:"token"`
+	}
+	type resBody struct {
+		User
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to verify email", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	verification, err := qtx.UseEmailVerificationToken(r.Context(), auth.HashToken(request.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Verification token is invalid or expired", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to verify email", err)
+		return
+	}
+	user, err := qtx.SetUserEmailVerified(r.Context(), database.SetUserEmailVerifiedParams{
+		Email: verification.Email,
+		ID:    verification.UserID,
+	})
+	if isUniqueViolation(err, "users_email_key") {
+		respondWithError(w, http.StatusConflict, "Email is already in use", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to verify email", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to verify email", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody{User: databaseUserToUser(user)})
+}
+
+func (cfg *apiConfig) handleEmailVerificationResend(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+
+	// An address change waiting to be verified takes precedence, even when
+	// the current address is already verified.
+	email := user.Email
+	pending, err := cfg.dbQueries.GetLatestUnusedVerificationEmail(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create verification token", err)
+		return
+	}
+	if err == nil && pending != user.Email {
+		email = pending
+	} else if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email is already verified", nil)
+		return
+	}
+
+	token, err := issueEmailVerificationToken(r.Context(), cfg.dbQueries, user.ID, email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create verification token", err)
+		return
+	}
+	cfg.sendEmailVerification(r.Context(), email, token)
+	w.WriteHeader(http.StatusAccepted)
+}
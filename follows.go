@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type FollowUser struct {
+	ID         uuid.UUID `json:"id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handleFollowCreate(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	followeeId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	if followeeId == userId {
+		respondWithError(w, http.StatusBadRequest, "You cannot follow yourself", nil)
+		return
+	}
+	if _, err := cfg.dbQueries.GetUserByID(r.Context(), followeeId); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	_, err = cfg.dbQueries.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to follow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleFollowDelete(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	followeeId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to unfollow user", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "You are not following this user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUserLists serves GET /api/users/{id}/{list}. The follow lists share a
+// single pattern because separate /followers and /following patterns would
+// conflict with /api/users/by-username/{name} in the mux.
+func (cfg *apiConfig) handleUserLists(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("list") {
+	case "followers":
+		cfg.handleFollowersList(w, r)
+	case "following":
+		cfg.handleFollowingList(w, r)
+	default:
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+	}
+}
+
+func (cfg *apiConfig) handleFollowersList(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, func(userId uuid.UUID, page pageParams) ([]FollowUser, error) {
+		cursorCreatedAt, cursorId := page.cursorArgs()
+		data, err := cfg.dbQueries.GetFollowers(r.Context(), database.GetFollowersParams{
+			UserID:          userId,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorId,
+			PageLimit:       page.Limit + 1,
+		})
+		users := []FollowUser{}
+		for _, val := range data {
+			users = append(users, FollowUser{ID: val.UserID, FollowedAt: val.CreatedAt})
+		}
+		return users, err
+	})
+}
+
+func (cfg *apiConfig) handleFollowingList(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, func(userId uuid.UUID, page pageParams) ([]FollowUser, error) {
+		cursorCreatedAt, cursorId := page.cursorArgs()
+		data, err := cfg.dbQueries.GetFollowing(r.Context(), database.GetFollowingParams{
+			UserID:          userId,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorId,
+			PageLimit:       page.Limit + 1,
+		})
+		users := []FollowUser{}
+		for _, val := range data {
+			users = append(users, FollowUser{ID: val.UserID, FollowedAt: val.CreatedAt})
+		}
+		return users, err
+	})
+}
+
+// respondWithFollowList handles the parts the followers and following lists
+// share: parsing the path and page, and building the next cursor.
+func (cfg *apiConfig) respondWithFollowList(w http.ResponseWriter, r *http.Request, fetch func(uuid.UUID, pageParams) ([]FollowUser, error)) {
+	type resBody struct {
+		Users      []FollowUser `json:"users"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}
+	userId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	users, err := fetch(userId, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get users", err)
+		return
+	}
+	res := resBody{
+		Users: users,
+	}
+	if len(users) > int(page.Limit) {
+		res.Users = users[:page.Limit]
+		last := res.Users[len(res.Users)-1]
+		res.NextCursor = pageCursor{CreatedAt: last.FollowedAt, ID: last.ID}.encode()
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
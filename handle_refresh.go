@@ -5,11 +5,20 @@ import (
 	"time"
 
 	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
 )
 
+// refreshTokenTTL is how long a refresh token stays valid if it is never
+// rotated.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// handleRefresh rotates the refresh token: the presented token is marked used
+// and a new token in the same family is returned. A used token being presented
+// again means it was copied, so the whole family is revoked.
 func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	type resBody struct {
-		AccessToken string `json:"token"`
+		AccessToken  string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 	refreskToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -17,20 +26,81 @@ func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.dbQueries.GetUserFromRefreshToken(r.Context(), refreskToken)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh token", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	current, err := qtx.GetRefreshTokenForUpdate(r.Context(), auth.HashToken(refreskToken))
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Refresh token not found", err)
 		return
 	}
+	if current.RevokedAt.Valid || !current.ExpiresAt.After(time.Now().UTC()) {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token not found", nil)
+		return
+	}
+	if current.UsedAt.Valid {
+		if err := qtx.RevokeRefreshTokenFamily(r.Context(), current.FamilyID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh tokens", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh tokens", err)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used", nil)
+		return
+	}
+	user, err := qtx.GetUserByID(r.Context(), current.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh token", err)
+		return
+	}
+	if refuseSuspended(w, user) {
+		return
+	}
 
-	jwt, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	if err := qtx.MarkRefreshTokenUsed(r.Context(), current.TokenHash); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh token", err)
+		return
+	}
+	if err := qtx.TouchSession(r.Context(), current.FamilyID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh token", err)
+		return
+	}
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create Refresh Token", err)
+		return
+	}
+	_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash: auth.HashToken(newRefreshToken),
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+		UserID:    current.UserID,
+		FamilyID:  current.FamilyID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create Refresh Token in db", err)
+		return
+	}
+
+	jwt, err := auth.MakeJWT(current.UserID, cfg.keyring, time.Hour)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unable to create JWt", err)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh token", err)
+		return
+	}
 
 	respondWithJSON(w, http.StatusOK, resBody{
-		AccessToken: jwt,
+		AccessToken:  jwt,
+		RefreshToken: newRefreshToken,
 	})
 }
 
@@ -41,7 +111,7 @@ func (cfg *apiConfig) handleRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = cfg.dbQueries.RevokeRefreshToken(r.Context(), refreskToken)
+	err = cfg.dbQueries.RevokeRefreshToken(r.Context(), auth.HashToken(refreskToken))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh token", err)
 		return
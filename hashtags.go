@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/entities"
+)
+
+const (
+	defaultTrendingWindow   = 24 * time.Hour
+	maxTrendingWindow       = 7 * 24 * time.Hour
+	trendingHalfLife        = 6 * time.Hour
+	defaultTrendingLimit    = 10
+	maxTrendingHashtagLimit = 100
+)
+
+type TrendingHashtag struct {
+	Tag   string  `json:"tag"`
+	Uses  int64   `json:"uses"`
+	Score float64 `json:"score"`
+}
+
+// indexChirpHashtags replaces the hashtags stored for a chirp with the ones
+// found in its current body. It runs inside the caller's transaction.
+func indexChirpHashtags(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if err := qtx.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, tag := range entities.Hashtags(chirp.Body) {
+		if seen[tag.Text] {
+			continue
+		}
+		seen[tag.Text] = true
+		hashtag, err := qtx.UpsertHashtag(ctx, tag.Text)
+		if err != nil {
+			return err
+		}
+		// Links carry the chirp's creation time so edits don't count as new uses.
+		err = qtx.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Tag is required", nil)
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetHashtagChirps(r.Context(), database.GetHashtagChirpsParams{
+		Tag:             tag,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirps for hashtag", err)
+		return
+	}
+	data, nextCursor := trimChirpPage(data, page.Limit)
+	chirps, err := cfg.renderChirps(r.Context(), cfg.viewerID(r), canSeeHiddenChirps(r), data)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirps for hashtag", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resBody{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handleTrendingHashtags ranks the tags used inside the window. Each use
+// counts less the older it is, halving every trendingHalfLife.
+func (cfg *apiConfig) handleTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if windowString := r.URL.Query().Get("window"); windowString != "" {
+		parsed, err := time.ParseDuration(windowString)
+		if err != nil || parsed <= 0 || parsed > maxTrendingWindow {
+			respondWithError(w, http.StatusBadRequest, "Window must be a duration up to "+maxTrendingWindow.String(), err)
+			return
+		}
+		window = parsed
+	}
+	limit := defaultTrendingLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		parsed, err := strconv.Atoi(limitString)
+		if err != nil || parsed < 1 || parsed > maxTrendingHashtagLimit {
+			respondWithError(w, http.StatusBadRequest, "Limit must be between 1 and "+strconv.Itoa(maxTrendingHashtagLimit), err)
+			return
+		}
+		limit = parsed
+	}
+	data, err := cfg.dbQueries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		WindowSeconds:   window.Seconds(),
+		PageLimit:       int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get trending hashtags", err)
+		return
+	}
+	trending := []TrendingHashtag{}
+	for _, val := range data {
+		trending = append(trending, TrendingHashtag{
+			Tag:   val.Tag,
+			Uses:  val.Uses,
+			Score: val.Score,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, trending)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
@@ -17,6 +18,9 @@ type TokenType string
 
 const (
 	TokenAccessType TokenType = "chirpy-access"
+	// TokenChallengeType proves the password was checked and a second factor
+	// is still required. It is never accepted as an access token.
+	TokenChallengeType TokenType = "chirpy-2fa-challenge"
 )
 
 func HashPassword(password string) (string, error) {
@@ -35,26 +39,47 @@ func CheckPasswordHash(password, hash string) (bool, error) {
 	return same, nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	return makeToken(userID, keyring, expiresIn, TokenAccessType)
+}
+
+func ValidateJWT(tokenString string, keyring *Keyring) (uuid.UUID, error) {
+	return validateToken(tokenString, keyring, TokenAccessType)
+}
+
+// MakeChallengeJWT issues the short-lived token handed out between the
+// password and the second factor of a login.
+func MakeChallengeJWT(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	return makeToken(userID, keyring, expiresIn, TokenChallengeType)
+}
+
+func ValidateChallengeJWT(tokenString string, keyring *Keyring) (uuid.UUID, error) {
+	return validateToken(tokenString, keyring, TokenChallengeType)
+}
+
+func makeToken(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration, tokenType TokenType) (string, error) {
+	key, err := keyring.signingKey()
+	if err != nil {
+		return "", err
+	}
 	now := time.Now()
 	expiryTime := now.Add(expiresIn)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenAccessType),
+	token := jwt.NewWithClaims(key.method, jwt.RegisteredClaims{
+		Issuer:    string(tokenType),
 		IssuedAt:  jwt.NewNumericDate(now),
 		ExpiresAt: jwt.NewNumericDate(expiryTime),
 		Subject:   userID.String(),
 	})
-	signedToken, err := token.SignedString([]byte(tokenSecret))
+	token.Header["kid"] = key.id
+	signedToken, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
 	return signedToken, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(tokenSecret), nil
-	})
+func validateToken(tokenString string, keyring *Keyring, tokenType TokenType) (uuid.UUID, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keyring.keyFunc)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -66,7 +91,7 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if issuer != string(TokenAccessType) {
+	if issuer != string(tokenType) {
 		return uuid.Nil, errors.New("invalid issuer")
 	}
 	parsedUuid, err := uuid.Parse(id)
@@ -96,6 +121,25 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// PersonalAccessTokenPrefix starts every personal access token so they can be
+// told apart from JWTs and spotted by secret scanners.
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+func MakePersonalAccessToken() (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
+
+// HashToken returns the hex SHA-256 digest of an opaque token so only the
+// digest needs to be stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetAPIKey(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keyring holds the keys used to sign and verify access tokens. The active key
+// signs new tokens; every key in the ring can still verify, so tokens signed
+// by a retiring key stay valid until they expire.
+type Keyring struct {
+	mu     sync.RWMutex
+	active *jwtKey
+	keys   map[string]*jwtKey
+	// legacy verifies HS256 tokens issued before tokens carried a kid.
+	legacy *jwtKey
+}
+
+type jwtKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	jwk       *JWK
+}
+
+// JWK is the public part of a key as published in a JWK Set (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string]*jwtKey{}}
+}
+
+// NewHMACKeyring returns a keyring that signs with HS256 using secret.
+func NewHMACKeyring(secret string) *Keyring {
+	k := NewKeyring()
+	k.SetActive(k.AddHMAC([]byte(secret)))
+	return k
+}
+
+// AddHMAC adds an HS256 secret and returns its kid. HMAC keys are never
+// published in the JWK Set.
+func (k *Keyring) AddHMAC(secret []byte) string {
+	id := thumbprint(map[string]string{
+		"kty": "oct",
+		"k":   base64.RawURLEncoding.EncodeToString(secret),
+	})
+	key := &jwtKey{id: id, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+	if k.legacy == nil {
+		k.legacy = key
+	}
+	return id
+}
+
+// AddPEM adds a PEM encoded key and returns its kid. A PKCS#8 private key can
+// sign and verify; a PKIX public key can only verify. Ed25519 keys use EdDSA
+// and RSA keys use RS256.
+func (k *Keyring) AddPEM(data []byte) (string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", errors.New("no PEM block found")
+	}
+	var signKey, pub any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return "", fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		signKey, pub = parsed, signer.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		pub = parsed
+	default:
+		return "", fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	return k.addAsymmetric(signKey, pub)
+}
+
+func (k *Keyring) addAsymmetric(signKey, pub any) (string, error) {
+	key := &jwtKey{signKey: signKey, verifyKey: pub}
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.jwk = &JWK{
+			Kty: "OKP",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+		key.id = thumbprint(map[string]string{"kty": "OKP", "crv": "Ed25519", "x": key.jwk.X})
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return "", errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.method = jwt.SigningMethodRS256
+		key.jwk = &JWK{
+			Kty: "RSA",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+		key.id = thumbprint(map[string]string{"kty": "RSA", "n": key.jwk.N, "e": key.jwk.E})
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+	key.jwk.Kid = key.id
+	key.jwk.Use = "sig"
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[key.id] = key
+	return key.id, nil
+}
+
+// SetActive makes the key with the given kid sign new tokens.
+func (k *Keyring) SetActive(kid string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown key %q", kid)
+	}
+	if key.signKey == nil {
+		return fmt.Errorf("key %q has no private part", kid)
+	}
+	k.active = key
+	return nil
+}
+
+// JWKS returns the public keys of the ring.
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.keys {
+		if key.jwk != nil {
+			set.Keys = append(set.Keys, *key.jwk)
+		}
+	}
+	return set
+}
+
+func (k *Keyring) signingKey() (*jwtKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.active == nil {
+		return nil, errors.New("keyring has no active key")
+	}
+	return k.active, nil
+}
+
+// keyFunc picks the verification key by the token's kid and refuses tokens
+// whose alg doesn't match that key.
+func (k *Keyring) keyFunc(token *jwt.Token) (any, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key := k.legacy
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		key = k.keys[id]
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint of the required members.
+// encoding/json sorts map keys, which gives the canonical member order.
+func thumbprint(members map[string]string) string {
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
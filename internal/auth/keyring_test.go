@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func privatePEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicPEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestKeyringRotation(t *testing.T) {
+	userID := uuid.New()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	keyring := NewKeyring()
+	hmacKid := keyring.AddHMAC([]byte("secret"))
+	edKid, err := keyring.AddPEM(privatePEM(t, edKey))
+	if err != nil {
+		t.Fatalf("AddPEM() error = %v", err)
+	}
+	rsaKid, err := keyring.AddPEM(privatePEM(t, rsaKey))
+	if err != nil {
+		t.Fatalf("AddPEM() error = %v", err)
+	}
+
+	tokens := map[string]string{}
+	for _, kid := range []string{hmacKid, edKid, rsaKid} {
+		if err := keyring.SetActive(kid); err != nil {
+			t.Fatalf("SetActive() error = %v", err)
+		}
+		tokens[kid], err = MakeJWT(userID, keyring, time.Hour)
+		if err != nil {
+			t.Fatalf("MakeJWT() error = %v", err)
+		}
+	}
+
+	// A verifier holding only the public Ed25519 key.
+	edVerifier := NewKeyring()
+	if _, err := edVerifier.AddPEM(publicPEM(t, edKey.Public())); err != nil {
+		t.Fatalf("AddPEM() error = %v", err)
+	}
+
+	// HS256 token from before tokens had a kid.
+	legacyToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenAccessType),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	}).SignedString([]byte("secret"))
+
+	// HS256 token claiming the kid of the Ed25519 key.
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenAccessType),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	})
+	confused.Header["kid"] = edKid
+	confusedToken, _ := confused.SignedString([]byte("secret"))
+
+	tests := []struct {
+		name    string
+		token   string
+		keyring *Keyring
+		wantErr bool
+	}{
+		{name: "HS256 token", token: tokens[hmacKid], keyring: keyring},
+		{name: "EdDSA token", token: tokens[edKid], keyring: keyring},
+		{name: "RS256 token", token: tokens[rsaKid], keyring: keyring},
+		{name: "Token without kid", token: legacyToken, keyring: keyring},
+		{name: "Public key only", token: tokens[edKid], keyring: edVerifier},
+		{name: "Unknown kid", token: tokens[rsaKid], keyring: edVerifier, wantErr: true},
+		{name: "No legacy key", token: legacyToken, keyring: edVerifier, wantErr: true},
+		{name: "Algorithm mismatch", token: confusedToken, keyring: keyring, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, err := ValidateJWT(tt.token, tt.keyring)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && gotUserID != userID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+			}
+		})
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	pub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	keyring := NewHMACKeyring("secret")
+	kid, err := keyring.AddPEM(privatePEM(t, edKey))
+	if err != nil {
+		t.Fatalf("AddPEM() error = %v", err)
+	}
+
+	set := keyring.JWKS()
+	if len(set.Keys) != 1 {
+		t.Fatalf("JWKS() has %d keys, want 1", len(set.Keys))
+	}
+	key := set.Keys[0]
+	if key.Kid != kid || key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" {
+		t.Errorf("JWKS() key = %+v", key)
+	}
+
+	// The kid is the thumbprint, so the same key always gets the same kid.
+	other := NewKeyring()
+	otherKid, _ := other.AddPEM(publicPEM(t, pub))
+	if otherKid != kid {
+		t.Errorf("kid = %v for the public key, want %v", otherKid, kid)
+	}
+
+	if err := other.SetActive(otherKid); err == nil {
+		t.Errorf("SetActive() on a public key succeeded, want error")
+	}
+}
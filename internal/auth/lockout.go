@@ -0,0 +1,26 @@
+package auth
+
+import "time"
+
+// LockoutPolicy decides how long login is blocked after repeated failures.
+// The first Threshold failures are free; each one after that doubles the
+// lockout, starting at Base and capped at Max.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+func (p LockoutPolicy) Duration(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	lockout := p.Base
+	for i := p.Threshold; i < failures; i++ {
+		lockout *= 2
+		if lockout >= p.Max {
+			return p.Max
+		}
+	}
+	return min(lockout, p.Max)
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDuration(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 5, Base: 30 * time.Second, Max: time.Hour}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "No failures", failures: 0, want: 0},
+		{name: "Below threshold", failures: 4, want: 0},
+		{name: "At threshold", failures: 5, want: 30 * time.Second},
+		{name: "Doubles", failures: 6, want: time.Minute},
+		{name: "Doubles again", failures: 8, want: 4 * time.Minute},
+		{name: "Capped", failures: 12, want: time.Hour},
+		{name: "Far past cap", failures: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Duration(tt.failures); got != tt.want {
+				t.Errorf("Duration(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Scopes a personal access token can be granted. Login sessions may do
+// everything.
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeFollowsWrite = "follows:write"
+	ScopeProfileWrite = "profile:write"
+)
+
+var ValidScopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeFollowsWrite, ScopeProfileWrite}
+
+// TokenKind is how a request authenticated.
+type TokenKind string
+
+const (
+	TokenKindSession  TokenKind = "session"
+	TokenKindPersonal TokenKind = "personal"
+)
+
+type Tier string
+
+const (
+	TierFree      Tier = "free"
+	TierChirpyRed Tier = "chirpy_red"
+)
+
+// Role is what a user may administer. Each role includes the ones below it.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+func ParseRole(s string) (Role, bool) {
+	role := Role(s)
+	_, ok := roleRank[role]
+	return role, ok
+}
+
+// AtLeast reports whether r includes min. Unknown roles include nothing.
+func (r Role) AtLeast(min Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[min]
+}
+
+// Principal is the user a request acts for.
+type Principal struct {
+	UserID uuid.UUID
+	Kind   TokenKind
+	// TokenID is set for personal access tokens.
+	TokenID uuid.NullUUID
+	Scopes  []string
+	Tier    Tier
+	Role    Role
+}
+
+func (p Principal) IsPersonalToken() bool {
+	return p.Kind == TokenKindPersonal
+}
+
+// HasScope reports whether the principal may act within scope. Only personal
+// access tokens are limited.
+func (p Principal) HasScope(scope string) bool {
+	return !p.IsPersonalToken() || slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx that carries p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// UserFromContext returns the principal stored by NewContext, if any.
+func UserFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
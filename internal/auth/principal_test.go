@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPrincipalHasScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		scope     string
+		want      bool
+	}{
+		{
+			name:      "Session may do anything",
+			principal: Principal{Kind: TokenKindSession},
+			scope:     ScopeChirpsWrite,
+			want:      true,
+		},
+		{
+			name:      "Token with scope",
+			principal: Principal{Kind: TokenKindPersonal, Scopes: []string{ScopeChirpsRead, ScopeChirpsWrite}},
+			scope:     ScopeChirpsWrite,
+			want:      true,
+		},
+		{
+			name:      "Token without scope",
+			principal: Principal{Kind: TokenKindPersonal, Scopes: []string{ScopeChirpsRead}},
+			scope:     ScopeChirpsWrite,
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		min  Role
+		want bool
+	}{
+		{name: "Admin is a moderator", role: RoleAdmin, min: RoleModerator, want: true},
+		{name: "Moderator is a moderator", role: RoleModerator, min: RoleModerator, want: true},
+		{name: "User is not a moderator", role: RoleUser, min: RoleModerator, want: false},
+		{name: "Moderator is not an admin", role: RoleModerator, min: RoleAdmin, want: false},
+		{name: "Unknown role", role: Role("root"), min: RoleUser, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.role.AtLeast(tt.min); got != tt.want {
+				t.Errorf("AtLeast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	if _, ok := UserFromContext(context.Background()); ok {
+		t.Errorf("UserFromContext() on an empty context returned ok")
+	}
+	want := Principal{UserID: uuid.New(), Kind: TokenKindSession, Tier: TierFree}
+	got, ok := UserFromContext(NewContext(context.Background(), want))
+	if !ok || got.UserID != want.UserID || got.Kind != want.Kind || got.Tier != want.Tier {
+		t.Errorf("UserFromContext() = %+v, %v, want %+v", got, ok, want)
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTPRFC6238(t *testing.T) {
+	// SHA1 test vectors from RFC 6238 appendix B.
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "94287082"},
+		{name: "1111111109", unix: 1111111109, want: "07081804"},
+		{name: "1111111111", unix: 1111111111, want: "14050471"},
+		{name: "1234567890", unix: 1234567890, want: "89005924"},
+		{name: "2000000000", unix: 2000000000, want: "69279037"},
+		{name: "20000000000", unix: 20000000000, want: "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hotp(key, uint64(tt.unix/totpPeriod), 8); got != tt.want {
+				t.Errorf("hotp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		at       time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "Current code", code: "081804", at: now, wantStep: step, wantOK: true},
+		{name: "Previous period", code: "081804", at: now.Add(totpPeriod * time.Second), wantStep: step, wantOK: true},
+		{name: "Too old", code: "081804", at: now.Add(3 * totpPeriod * time.Second)},
+		{name: "Wrong code", code: "123456", at: now},
+		{name: "Wrong length", code: "81804", at: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := ValidateTOTP(secret, tt.code, tt.at)
+			if gotOK != tt.wantOK || (tt.wantOK && gotStep != tt.wantStep) {
+				t.Errorf("ValidateTOTP() = %v, %v, want %v, %v", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	got := TOTPURI("Chirpy", "user@example.com", "JBSWY3DPEHPK3PXP")
+	want := "otpauth://totp/Chirpy:user@example.com?algorithm=SHA1&digits=6&issuer=Chirpy&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Errorf("TOTPURI() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || strings.Index(code, "-") != 5 {
+			t.Errorf("code %q is not in xxxxx-xxxxx format", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q repeated", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,99 @@
+// Package entities finds hashtags and other markup inside chirp bodies.
+package entities
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Entity is one occurrence of markup in a body. Start and End are byte
+// offsets into the body, with Start pointing at the sigil.
+type Entity struct {
+	Text  string
+	Start int
+	End   int
+}
+
+const (
+	maxHashtagLength  = 100
+	minUsernameLength = 3
+	maxUsernameLength = 30
+)
+
+// Hashtags returns every #tag in body, in order of appearance. Tags are
+// lowercased; a tag needs at least one letter so "#1" is not a tag.
+func Hashtags(body string) []Entity {
+	tags := []Entity{}
+	for _, entity := range find(body, '#') {
+		if utf8.RuneCountInString(entity.Text) > maxHashtagLength || !strings.ContainsFunc(entity.Text, unicode.IsLetter) {
+			continue
+		}
+		entity.Text = strings.ToLower(entity.Text)
+		tags = append(tags, entity)
+	}
+	return tags
+}
+
+// Mentions returns every @handle in body that could be a username, in order
+// of appearance. Text keeps the handle as written, without the @.
+func Mentions(body string) []Entity {
+	mentions := []Entity{}
+	for _, entity := range find(body, '@') {
+		if !ValidUsername(entity.Text) {
+			continue
+		}
+		mentions = append(mentions, entity)
+	}
+	return mentions
+}
+
+// ValidUsername reports whether name can be used as a username: 3 to 30
+// ASCII letters, digits or underscores.
+func ValidUsername(name string) bool {
+	if len(name) < minUsernameLength || len(name) > maxUsernameLength {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the runs of word characters that follow sigil. The sigil only
+// counts at the start of the body or after a non-word character, so "a#b" is
+// not an entity.
+func find(body string, sigil byte) []Entity {
+	var found []Entity
+	for i := 0; i < len(body); i++ {
+		if body[i] != sigil {
+			continue
+		}
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(body[:i])
+			if isWordRune(prev) {
+				continue
+			}
+		}
+		end := i + 1
+		for end < len(body) {
+			r, size := utf8.DecodeRuneInString(body[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+		if end == i+1 {
+			continue
+		}
+		found = append(found, Entity{Text: body[i+1 : end], Start: i, End: end})
+		i = end - 1
+	}
+	return found
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
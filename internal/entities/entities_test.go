@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "No hashtags",
+			body: "just a chirp",
+			want: []Entity{},
+		},
+		{
+			name: "Lowercases tags",
+			body: "Loving #GoLang today",
+			want: []Entity{{Text: "golang", Start: 7, End: 14}},
+		},
+		{
+			name: "Stops at punctuation",
+			body: "#chirpy, #go!",
+			want: []Entity{{Text: "chirpy", Start: 0, End: 7}, {Text: "go", Start: 9, End: 12}},
+		},
+		{
+			name: "Ignores sigil inside a word",
+			body: "issue#12 and c#",
+			want: []Entity{},
+		},
+		{
+			name: "Needs a letter",
+			body: "#1 #2024",
+			want: []Entity{},
+		},
+		{
+			name: "Unicode tags use byte offsets",
+			body: "é #café",
+			want: []Entity{{Text: "café", Start: 3, End: 9}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "Keeps handle as written",
+			body: "hey @Alice_1!",
+			want: []Entity{{Text: "Alice_1", Start: 4, End: 12}},
+		},
+		{
+			name: "Ignores email addresses",
+			body: "mail me@example.com",
+			want: []Entity{},
+		},
+		{
+			name: "Ignores handles that cannot be usernames",
+			body: "@al @josé @bob",
+			want: []Entity{{Text: "bob", Start: 11, End: 15}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileOutboxSend(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     Message
+		wantErr bool
+	}{
+		{
+			name: "Valid message",
+			msg:  Message{To: "user@example.com", Subject: "Hello", Body: "line one\nline two"},
+		},
+		{
+			name:    "Missing recipient",
+			msg:     Message{Subject: "Hello", Body: "body"},
+			wantErr: true,
+		},
+		{
+			name:    "Header injection",
+			msg:     Message{To: "user@example.com", Subject: "Hi\r\nBcc: evil@example.com", Body: "body"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox, err := NewFileOutbox(t.TempDir(), "chirpy@example.com")
+			if err != nil {
+				t.Fatalf("NewFileOutbox() error = %v", err)
+			}
+			err = outbox.Send(context.Background(), tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			files, _ := filepath.Glob(filepath.Join(outbox.Dir, "*.eml"))
+			if tt.wantErr {
+				if len(files) != 0 {
+					t.Errorf("Send() wrote %d files, want 0", len(files))
+				}
+				return
+			}
+			if len(files) != 1 {
+				t.Fatalf("Send() wrote %d files, want 1", len(files))
+			}
+			data, _ := os.ReadFile(files[0])
+			for _, want := range []string{"To: user@example.com\r\n", "Subject: Hello\r\n", "\r\n\r\nline one\r\nline two"} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("message missing %q:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSMTPMailerSendStalledServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel bool
+	}{
+		{name: "Deadline"},
+		{name: "Cancelled", cancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The server accepts the connection but never sends a greeting.
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.Listen() error = %v", err)
+			}
+			defer ln.Close()
+			go func() {
+				conn, err := ln.Accept()
+				if err == nil {
+					defer conn.Close()
+					time.Sleep(5 * time.Second)
+				}
+			}()
+
+			var ctx context.Context
+			var cancel context.CancelFunc
+			if tt.cancel {
+				ctx, cancel = context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+			} else {
+				ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+			}
+			defer cancel()
+
+			m := &SMTPMailer{Addr: ln.Addr().String(), From: "chirpy@example.com"}
+			start := time.Now()
+			err = m.Send(ctx, Message{To: "user@example.com", Subject: "Hello", Body: "body"})
+			if err == nil {
+				t.Fatal("Send() error = nil, want an error")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Send() took %v, want it to stop with ctx", elapsed)
+			}
+		})
+	}
+}
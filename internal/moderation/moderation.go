@@ -0,0 +1,259 @@
+// Package moderation checks chirp bodies against configurable content
+// rules before they are stored.
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Kind is how a rule's pattern is matched.
+type Kind string
+
+const (
+	// KindWord matches one whole word, ignoring case.
+	KindWord Kind = "word"
+	// KindRegex matches an RE2 regular expression anywhere in the text.
+	KindRegex Kind = "regex"
+)
+
+// Action is what happens to text a rule matches.
+type Action string
+
+const (
+	// ActionMask replaces the match with Mask.
+	ActionMask Action = "mask"
+	// ActionReject refuses the whole text.
+	ActionReject Action = "reject"
+	// ActionFlag keeps the text but asks a moderator to look at it.
+	ActionFlag Action = "flag"
+)
+
+// Mask is what masked matches are replaced with.
+const Mask = "****"
+
+const maxPatternLength = 200
+
+// Rule is one configured content rule.
+type Rule struct {
+	ID      uuid.UUID
+	Kind    Kind
+	Pattern string
+	Action  Action
+}
+
+// ParseRule checks a rule as entered by an admin and returns it with the
+// pattern normalised: words are trimmed and lowercased. The error is meant
+// for the admin.
+func ParseRule(kind, pattern, action string) (Rule, error) {
+	rule := Rule{Kind: Kind(kind), Pattern: pattern, Action: Action(action)}
+	switch rule.Action {
+	case ActionMask, ActionReject, ActionFlag:
+	default:
+		return Rule{}, errors.New("Action must be mask, reject or flag")
+	}
+	if pattern == "" {
+		return Rule{}, errors.New("Pattern is required")
+	}
+	if len(pattern) > maxPatternLength {
+		return Rule{}, errors.New("Pattern is too long")
+	}
+	switch rule.Kind {
+	case KindWord:
+		rule.Pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if rule.Pattern == "" || strings.IndexFunc(rule.Pattern, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+			return Rule{}, errors.New("A word pattern must be a single word")
+		}
+	case KindRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return Rule{}, fmt.Errorf("Pattern is not a valid regular expression: %w", err)
+		}
+	default:
+		return Rule{}, errors.New("Kind must be word or regex")
+	}
+	return rule, nil
+}
+
+// Match is one span of text a rule matched. Start and End are byte offsets.
+type Match struct {
+	RuleID uuid.UUID
+	Action Action
+	Start  int
+	End    int
+}
+
+// Filter finds the parts of a text its rules match.
+type Filter interface {
+	Match(text string) []Match
+}
+
+// WordFilter matches whole words. Words are runs of letters, digits, marks
+// and underscores, so punctuation next to a word doesn't hide it and a word
+// inside a longer one isn't matched.
+type WordFilter struct {
+	words map[string]Rule
+}
+
+// NewWordFilter builds a filter from word rules. Other kinds are ignored.
+func NewWordFilter(rules []Rule) *WordFilter {
+	f := &WordFilter{words: map[string]Rule{}}
+	for _, rule := range rules {
+		if rule.Kind == KindWord {
+			f.words[strings.ToLower(rule.Pattern)] = rule
+		}
+	}
+	return f
+}
+
+func (f *WordFilter) Match(text string) []Match {
+	var matches []Match
+	start := -1
+	check := func(end int) {
+		if rule, ok := f.words[strings.ToLower(text[start:end])]; ok {
+			matches = append(matches, Match{RuleID: rule.ID, Action: rule.Action, Start: start, End: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			check(i)
+		}
+	}
+	if start >= 0 {
+		check(len(text))
+	}
+	return matches
+}
+
+// RegexFilter matches one regex rule.
+type RegexFilter struct {
+	rule Rule
+	re   *regexp.Regexp
+}
+
+func NewRegexFilter(rule Rule) (*RegexFilter, error) {
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexFilter{rule: rule, re: re}, nil
+}
+
+func (f *RegexFilter) Match(text string) []Match {
+	var matches []Match
+	for _, loc := range f.re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		matches = append(matches, Match{RuleID: f.rule.ID, Action: f.rule.Action, Start: loc[0], End: loc[1]})
+	}
+	return matches
+}
+
+// Pipeline runs a text through a chain of filters.
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Compile builds the pipeline for a rule set: one filter for every word
+// rule, then one per regex rule.
+func Compile(rules []Rule) (*Pipeline, error) {
+	filters := []Filter{NewWordFilter(rules)}
+	for _, rule := range rules {
+		switch rule.Kind {
+		case KindWord:
+		case KindRegex:
+			f, err := NewRegexFilter(rule)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+			filters = append(filters, f)
+		default:
+			return nil, fmt.Errorf("rule %s: unknown kind %q", rule.ID, rule.Kind)
+		}
+	}
+	return NewPipeline(filters...), nil
+}
+
+// Result is what a pipeline made of a text.
+type Result struct {
+	// Text has every masked match replaced with Mask.
+	Text    string
+	Matches []Match
+}
+
+// Rejected reports whether a reject rule matched.
+func (r Result) Rejected() bool {
+	return r.has(ActionReject)
+}
+
+// Flagged reports whether a flag rule matched.
+func (r Result) Flagged() bool {
+	return r.has(ActionFlag)
+}
+
+func (r Result) has(action Action) bool {
+	for _, m := range r.Matches {
+		if m.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply runs text through every filter. Matches are looked for in the
+// original text, so one rule masking a word can't hide it from the rest. A
+// nil pipeline leaves text alone.
+func (p *Pipeline) Apply(text string) Result {
+	res := Result{Text: text}
+	if p == nil {
+		return res
+	}
+	var masked []Match
+	for _, f := range p.filters {
+		for _, m := range f.Match(text) {
+			res.Matches = append(res.Matches, m)
+			if m.Action == ActionMask {
+				masked = append(masked, m)
+			}
+		}
+	}
+	if len(masked) == 0 {
+		return res
+	}
+
+	// Overlapping masks are merged so each masked stretch shows one Mask.
+	sort.Slice(masked, func(i, j int) bool { return masked[i].Start < masked[j].Start })
+	var b strings.Builder
+	last := 0
+	for _, m := range masked {
+		if m.Start < last {
+			last = max(last, m.End)
+			continue
+		}
+		b.WriteString(text[last:m.Start])
+		b.WriteString(Mask)
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	res.Text = b.String()
+	return res
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
@@ -0,0 +1,134 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPipelineApply(t *testing.T) {
+	profane := []Rule{
+		{ID: uuid.New(), Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask},
+		{ID: uuid.New(), Kind: KindWord, Pattern: "sharbert", Action: ActionMask},
+		{ID: uuid.New(), Kind: KindWord, Pattern: "fornax", Action: ActionMask},
+	}
+	tests := []struct {
+		name         string
+		rules        []Rule
+		text         string
+		wantText     string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		{
+			name:     "Clean text",
+			rules:    profane,
+			text:     "This is a clean chirp",
+			wantText: "This is a clean chirp",
+		},
+		{
+			name:     "Masks regardless of case",
+			rules:    profane,
+			text:     "What a Kerfuffle and SHARBERT",
+			wantText: "What a **** and ****",
+		},
+		{
+			name:     "Masks words next to punctuation",
+			rules:    profane,
+			text:     "Kerfuffle! fornax, (sharbert)",
+			wantText: "****! ****, (****)",
+		},
+		{
+			name:     "Leaves longer words alone",
+			rules:    profane,
+			text:     "kerfuffles and fornaxes",
+			wantText: "kerfuffles and fornaxes",
+		},
+		{
+			name:     "Unicode words",
+			rules:    []Rule{{ID: uuid.New(), Kind: KindWord, Pattern: "éclair", Action: ActionMask}},
+			text:     "Un ÉCLAIR, s'il vous plaît",
+			wantText: "Un ****, s'il vous plaît",
+		},
+		{
+			name:         "Regex rejects",
+			rules:        []Rule{{ID: uuid.New(), Kind: KindRegex, Pattern: `(?i)buy\s+now`, Action: ActionReject}},
+			text:         "BUY   now!",
+			wantText:     "BUY   now!",
+			wantRejected: true,
+		},
+		{
+			name:        "Flag keeps the text",
+			rules:       []Rule{{ID: uuid.New(), Kind: KindRegex, Pattern: `\d{3}-\d{4}`, Action: ActionFlag}},
+			text:        "call 555-1234",
+			wantText:    "call 555-1234",
+			wantFlagged: true,
+		},
+		{
+			name: "Overlapping masks are merged",
+			rules: []Rule{
+				{ID: uuid.New(), Kind: KindWord, Pattern: "fornax", Action: ActionMask},
+				{ID: uuid.New(), Kind: KindRegex, Pattern: `fornax \w+`, Action: ActionMask},
+			},
+			text:     "a fornax cluster here",
+			wantText: "a **** here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := Compile(tt.rules)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			res := pipeline.Apply(tt.text)
+			if res.Text != tt.wantText {
+				t.Errorf("Apply().Text = %q, want %q", res.Text, tt.wantText)
+			}
+			if res.Rejected() != tt.wantRejected {
+				t.Errorf("Apply().Rejected() = %v, want %v", res.Rejected(), tt.wantRejected)
+			}
+			if res.Flagged() != tt.wantFlagged {
+				t.Errorf("Apply().Flagged() = %v, want %v", res.Flagged(), tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestNilPipeline(t *testing.T) {
+	var pipeline *Pipeline
+	if res := pipeline.Apply("kerfuffle"); res.Text != "kerfuffle" || len(res.Matches) != 0 {
+		t.Errorf("Apply() = %+v, want text unchanged", res)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        string
+		pattern     string
+		action      string
+		wantPattern string
+		wantErr     bool
+	}{
+		{name: "Word is lowercased", kind: "word", pattern: " Fornax ", action: "mask", wantPattern: "fornax"},
+		{name: "Regex is kept as written", kind: "regex", pattern: `(?i)spam+`, action: "flag", wantPattern: `(?i)spam+`},
+		{name: "Word with a space", kind: "word", pattern: "two words", action: "mask", wantErr: true},
+		{name: "Invalid regex", kind: "regex", pattern: "(", action: "reject", wantErr: true},
+		{name: "Unknown kind", kind: "glob", pattern: "*", action: "mask", wantErr: true},
+		{name: "Unknown action", kind: "word", pattern: "fornax", action: "delete", wantErr: true},
+		{name: "Empty pattern", kind: "word", pattern: "", action: "mask", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.kind, tt.pattern, tt.action)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && rule.Pattern != tt.wantPattern {
+				t.Errorf("ParseRule() pattern = %q, want %q", rule.Pattern, tt.wantPattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import "net/http"
+
+// handleJWKS publishes the public signing keys so other services can verify
+// access tokens without sharing a secret.
+func (cfg *apiConfig) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.keyring.JWKS())
+}
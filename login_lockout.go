@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+)
+
+// errLoginFailed is the only failure message a login gives, so it can't be
+// used to find out which emails are registered.
+const errLoginFailed = "Incorrect email or password"
+
+var (
+	emailLockoutPolicy = auth.LockoutPolicy{Threshold: 5, Base: 30 * time.Second, Max: time.Hour}
+	// Many users can share an address behind NAT, so IPs get more slack.
+	ipLockoutPolicy = auth.LockoutPolicy{Threshold: 20, Base: 30 * time.Second, Max: time.Hour}
+)
+
+// dummyPasswordHash is compared against when the email is unknown so that a
+// failed login takes as long whether or not the account exists.
+var dummyPasswordHash = sync.OnceValues(func() (string, error) {
+	return auth.HashPassword("chirpy-dummy-password")
+})
+
+func loginEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLoginLockout responds with 429 and returns false while the email or the
+// client IP is locked out.
+func (cfg *apiConfig) checkLoginLockout(w http.ResponseWriter, r *http.Request, email string) bool {
+	seconds, err := cfg.dbQueries.GetLoginLockoutSeconds(r.Context(), database.GetLoginLockoutSecondsParams{
+		Email: loginEmailKey(email),
+		Ip:    clientIP(r),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to log in", err)
+		return false
+	}
+	if seconds > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+		respondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure counts a failed attempt against the email and the IP and
+// locks them out once their policy says so. Errors are logged rather than
+// returned because the caller is already failing the login.
+func (cfg *apiConfig) recordLoginFailure(ctx context.Context, email, ip string) {
+	record := func(scope, key string, policy auth.LockoutPolicy) {
+		failures, err := cfg.dbQueries.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+			Scope: scope,
+			Key:   key,
+		})
+		if err != nil {
+			log.Printf("Unable to record login failure: %s", err)
+			return
+		}
+		lockout := policy.Duration(int(failures))
+		if lockout == 0 {
+			return
+		}
+		err = cfg.dbQueries.SetLoginLockout(ctx, database.SetLoginLockoutParams{
+			LockoutSeconds: lockout.Seconds(),
+			Scope:          scope,
+			Key:            key,
+		})
+		if err != nil {
+			log.Printf("Unable to lock out login: %s", err)
+		}
+	}
+	record("email", loginEmailKey(email), emailLockoutPolicy)
+	record("ip", ip, ipLockoutPolicy)
+}
+
+// clearLoginFailures resets the email's counter after a successful login.
+// The IP counter is left alone so an attacker can't reset it by logging into
+// an account of their own.
+func (cfg *apiConfig) clearLoginFailures(ctx context.Context, email string) {
+	err := cfg.dbQueries.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{
+		Scope: "email",
+		Key:   loginEmailKey(email),
+	})
+	if err != nil {
+		log.Printf("Unable to clear login failures: %s", err)
+	}
+}
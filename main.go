@@ -1,27 +1,39 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync/atomic"
 
+	"github.com/MaazU-Dev/chirpy/internal/auth"
 	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/mailer"
+	"github.com/MaazU-Dev/chirpy/internal/moderation"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
-	jwtSecret      string
+	keyring        *auth.Keyring
 	polkaApiKey    string
+	mailer         mailer.Mailer
+	contentFilter  atomic.Pointer[moderation.Pipeline]
 }
 
 func main() {
+	bootstrapAdminEmail := flag.String("bootstrap-admin", "", "promote the user with this email to admin and exit")
+	flag.Parse()
 	godotenv.Load()
 	dbUrl := os.Getenv("DB_URL")
 	db, err := sql.Open("postgres", dbUrl)
@@ -30,41 +42,110 @@ func main() {
 	}
 	defer db.Close()
 	dbQueries := database.New(db)
+	if *bootstrapAdminEmail != "" {
+		if err := bootstrapAdmin(context.Background(), db, dbQueries, *bootstrapAdminEmail); err != nil {
+			log.Fatalf("Unable to bootstrap admin: %s", err)
+		}
+		log.Printf("%s is now an admin", *bootstrapAdminEmail)
+		return
+	}
 	platform := os.Getenv("platform")
 	if platform == "" {
 		log.Fatal("PLATFORM must be set")
 	}
-	jwtSecret := os.Getenv("JWT_SECRET_TOKEN")
-	if platform == "" {
-		log.Fatal("Please set JWT Secret Token")
+	keyring, err := newKeyring()
+	if err != nil {
+		log.Fatal(err)
 	}
 	polkaApiKey := os.Getenv("POLKA_API_KEY")
 	if polkaApiKey == "" {
 		log.Fatal("Please set JWT Secret Token")
 	}
+	mail, err := newMailer()
+	if err != nil {
+		log.Fatal(err)
+	}
 	rootFileDir := "."
 	port := "8080"
 	mux := http.NewServeMux()
 	config := apiConfig{
 		fileserverHits: atomic.Int32{},
+		db:             db,
 		dbQueries:      dbQueries,
 		platform:       platform,
-		jwtSecret:      jwtSecret,
+		keyring:        keyring,
 		polkaApiKey:    polkaApiKey,
+		mailer:         mail,
+	}
+	if err := config.reloadContentFilter(context.Background()); err != nil {
+		log.Fatalf("Unable to load content filter: %s", err)
 	}
+	go config.watchContentFilter(contentFilterReloadInterval)
 	mux.Handle("/app/", http.StripPrefix("/app/", config.middlewareMetricsInc(http.FileServer(http.Dir(rootFileDir)))))
-	mux.HandleFunc("GET /admin/metrics", config.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", config.handlerReset)
+	// Everything under /admin/ needs at least a moderator; each route can
+	// require more.
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc("GET /admin/metrics", config.RequireRole(auth.RoleAdmin, config.handlerMetrics))
+	adminMux.HandleFunc("POST /admin/reset", config.RequireRole(auth.RoleAdmin, config.handlerReset))
+	adminMux.HandleFunc("GET /admin/audit-log", config.RequireRole(auth.RoleAdmin, config.handleAuditLog))
+	adminMux.HandleFunc("GET /admin/users", config.handleAdminUsersList)
+	adminMux.HandleFunc("GET /admin/users/{id}", config.handleAdminUserGet)
+	adminMux.HandleFunc("PUT /admin/users/{id}/role", config.RequireRole(auth.RoleAdmin, config.handleAdminUserRole))
+	adminMux.HandleFunc("POST /admin/users/{id}/suspend", config.handleAdminUserSuspend)
+	adminMux.HandleFunc("POST /admin/users/{id}/unsuspend", config.handleAdminUserUnsuspend)
+	adminMux.HandleFunc("POST /admin/users/{id}/logout", config.handleAdminUserLogout)
+	adminMux.HandleFunc("PUT /admin/users/{id}/chirpy-red", config.RequireRole(auth.RoleAdmin, config.handleAdminChirpyRedGrant))
+	adminMux.HandleFunc("DELETE /admin/users/{id}/chirpy-red", config.RequireRole(auth.RoleAdmin, config.handleAdminChirpyRedRevoke))
+	adminMux.HandleFunc("GET /admin/reports", config.handleReportQueue)
+	adminMux.HandleFunc("POST /admin/reports/{id}/resolve", config.handleReportResolve)
+	adminMux.HandleFunc("GET /admin/filter-rules", config.RequireRole(auth.RoleAdmin, config.handleFilterRulesList))
+	adminMux.HandleFunc("POST /admin/filter-rules", config.RequireRole(auth.RoleAdmin, config.handleFilterRuleCreate))
+	adminMux.HandleFunc("PUT /admin/filter-rules/{id}", config.RequireRole(auth.RoleAdmin, config.handleFilterRuleUpdate))
+	adminMux.HandleFunc("DELETE /admin/filter-rules/{id}", config.RequireRole(auth.RoleAdmin, config.handleFilterRuleDelete))
+	mux.Handle("/admin/", config.RequireRole(auth.RoleModerator, adminMux.ServeHTTP))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", config.handleJWKS)
 	mux.HandleFunc("POST /api/users", config.handleUsersCreate)
-	mux.HandleFunc("PUT /api/users", config.handleUsersUpdate)
+	mux.HandleFunc("PUT /api/users", config.RequireAuth(auth.ScopeProfileWrite, config.handleUsersUpdate))
+	mux.HandleFunc("PATCH /api/users", config.RequireAuth(auth.ScopeProfileWrite, config.handleUsersUpdate))
+	mux.HandleFunc("POST /api/users/verify", config.handleEmailVerify)
+	mux.HandleFunc("POST /api/users/verify/resend", config.RequireLogin(config.handleEmailVerificationResend))
+	mux.HandleFunc("GET /api/users/me/mentions", config.RequireAuth(auth.ScopeChirpsRead, config.handleMyMentions))
+	mux.HandleFunc("POST /api/users/me/2fa/setup", config.RequireLogin(config.handleTwoFactorSetup))
+	mux.HandleFunc("POST /api/users/me/2fa/confirm", config.RequireLogin(config.handleTwoFactorConfirm))
+	mux.HandleFunc("POST /api/users/{id}/follow", config.RequireAuth(auth.ScopeFollowsWrite, config.handleFollowCreate))
+	mux.HandleFunc("DELETE /api/users/{id}/follow", config.RequireAuth(auth.ScopeFollowsWrite, config.handleFollowDelete))
+	mux.HandleFunc("GET /api/users/{id}", config.handleUserProfile)
+	mux.HandleFunc("GET /api/users/{id}/{list}", config.handleUserLists)
+	mux.HandleFunc("GET /api/users/by-username/{name}", config.handleUserProfileByUsername)
 	mux.HandleFunc("POST /api/login", config.handleLogin)
+	mux.HandleFunc("POST /api/login/2fa", config.handleLoginTwoFactor)
 	mux.HandleFunc("POST /api/refresh", config.handleRefresh)
 	mux.HandleFunc("POST /api/revoke", config.handleRevoke)
-	mux.HandleFunc("POST /api/chirps", config.handleChirpsCreate)
-	mux.HandleFunc("GET /api/chirps", config.handleChirpsRetrieve)
-	mux.HandleFunc("GET /api/chirps/{id}", config.handleChirpsRetrieveByID)
-	mux.HandleFunc("DELETE /api/chirps/{id}", config.HandleChirpsDeleteByID)
+	mux.HandleFunc("POST /api/tokens", config.RequireLogin(config.handleTokensCreate))
+	mux.HandleFunc("GET /api/tokens", config.RequireLogin(config.handleTokensList))
+	mux.HandleFunc("DELETE /api/tokens/{id}", config.RequireLogin(config.handleTokenRevoke))
+	mux.HandleFunc("GET /api/sessions", config.RequireLogin(config.handleSessionsList))
+	mux.HandleFunc("DELETE /api/sessions/{id}", config.RequireLogin(config.handleSessionRevoke))
+	mux.HandleFunc("POST /api/sessions/revoke-all", config.RequireLogin(config.handleSessionsRevokeAll))
+	mux.HandleFunc("POST /api/password-reset", config.handlePasswordResetRequest)
+	mux.HandleFunc("POST /api/password-reset/confirm", config.handlePasswordResetConfirm)
+	mux.HandleFunc("GET /api/timeline", config.RequireAuth(auth.ScopeChirpsRead, config.handleTimeline))
+	mux.HandleFunc("POST /api/chirps", config.RequireAuth(auth.ScopeChirpsWrite, config.handleChirpsCreate))
+	mux.HandleFunc("GET /api/chirps", config.OptionalAuth(config.handleChirpsRetrieve))
+	mux.HandleFunc("GET /api/chirps/search", config.OptionalAuth(config.handleChirpsSearch))
+	mux.HandleFunc("GET /api/chirps/{id}", config.OptionalAuth(config.handleChirpsRetrieveByID))
+	mux.HandleFunc("PUT /api/chirps/{id}", config.RequireAuth(auth.ScopeChirpsWrite, config.handleChirpsUpdate))
+	mux.HandleFunc("DELETE /api/chirps/{id}", config.RequireAuth(auth.ScopeChirpsWrite, config.HandleChirpsDeleteByID))
+	mux.HandleFunc("GET /api/chirps/{id}/revisions", config.OptionalAuth(config.handleChirpRevisions))
+	mux.HandleFunc("GET /api/chirps/{id}/thread", config.OptionalAuth(config.handleChirpThread))
+	mux.HandleFunc("POST /api/chirps/{id}/like", config.RequireAuth(auth.ScopeChirpsWrite, config.handleChirpLike))
+	mux.HandleFunc("DELETE /api/chirps/{id}/like", config.RequireAuth(auth.ScopeChirpsWrite, config.handleChirpUnlike))
+	mux.HandleFunc("GET /api/chirps/{id}/likes", config.OptionalAuth(config.handleChirpLikesList))
+	mux.HandleFunc("POST /api/chirps/{id}/rechirp", config.RequireAuth(auth.ScopeChirpsWrite, config.handleRechirp))
+	mux.HandleFunc("POST /api/chirps/{id}/report", config.RequireAuth(auth.ScopeChirpsWrite, config.handleChirpReport))
+	mux.HandleFunc("GET /api/hashtags/trending", config.handleTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", config.OptionalAuth(config.handleHashtagChirps))
 	mux.HandleFunc("POST /api/polka/webhooks", config.handlePolkaWebhook)
 	s := &http.Server{
 		Addr:    ":" + port,
@@ -73,6 +154,74 @@ func main() {
 	s.ListenAndServe()
 }
 
+// newKeyring signs access tokens with the PEM private key in
+// JWT_SIGNING_KEY_FILE, or with JWT_SECRET_TOKEN (HS256) if no key file is
+// set. JWT_VERIFY_KEY_FILES lists retiring keys, comma separated, that still
+// verify tokens. When both are set, the secret only verifies older tokens.
+func newKeyring() (*auth.Keyring, error) {
+	keyring := auth.NewKeyring()
+	secretKid := ""
+	if secret := os.Getenv("JWT_SECRET_TOKEN"); secret != "" {
+		secretKid = keyring.AddHMAC([]byte(secret))
+	}
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if _, err := addKeyFile(keyring, path); err != nil {
+			return nil, err
+		}
+	}
+	activeKid := secretKid
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		kid, err := addKeyFile(keyring, path)
+		if err != nil {
+			return nil, err
+		}
+		activeKid = kid
+	}
+	if activeKid == "" {
+		return nil, errors.New("Please set JWT_SIGNING_KEY_FILE or JWT_SECRET_TOKEN")
+	}
+	if err := keyring.SetActive(activeKid); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func addKeyFile(keyring *auth.Keyring, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	kid, err := keyring.AddPEM(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return kid, nil
+}
+
+// newMailer sends through SMTP when SMTP_HOST is set and otherwise writes
+// messages to MAIL_OUTBOX_DIR so development works offline.
+func newMailer() (mailer.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@chirpy.local"
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return mailer.NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	}
+	outboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+	if outboxDir == "" {
+		outboxDir = "outbox"
+	}
+	return mailer.NewFileOutbox(outboxDir, from)
+}
+
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+// Mention is an @handle in a chirp body that resolved to a user. Start and End
+// are byte offsets into the body.
+type Mention struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Start    int32     `json:"start"`
+	End      int32     `json:"end"`
+}
+
+// indexChirpMentions replaces the mentions stored for a chirp with the
+// handles in its current body that belong to a user. It runs inside the
+// caller's transaction.
+func indexChirpMentions(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if err := qtx.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+	usernames := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		usernames = append(usernames, strings.ToLower(mention.Text))
+	}
+	users, err := qtx.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	userIds := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIds[strings.ToLower(user.Username.String)] = user.ID
+	}
+	for _, mention := range mentions {
+		userId, ok := userIds[strings.ToLower(mention.Text)]
+		if !ok {
+			continue
+		}
+		err := qtx.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userId,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleMyMentions lists the chirps that mention the caller, newest first.
+func (cfg *apiConfig) handleMyMentions(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+	userId := currentUser(r).UserID
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetMentioningChirps(r.Context(), database.GetMentioningChirpsParams{
+		UserID:          userId,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get mentions", err)
+		return
+	}
+	data, nextCursor := trimChirpPage(data, page.Limit)
+	chirps, err := cfg.renderChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), data)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get mentions", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resBody{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
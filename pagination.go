@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor is the keyset position of the last row on a page. It is handed
+// to clients as an opaque base64 string.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	// Rank is only set for ranked search results.
+	Rank float32 `json:"r,omitempty"`
+}
+
+type pageParams struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return c, errors.New("incomplete cursor")
+	}
+	return c, nil
+}
+
+func parsePageParams(r *http.Request) (pageParams, error) {
+	params := pageParams{Limit: defaultPageLimit}
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, errors.New("Limit must be between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		params.Limit = int32(limit)
+	}
+	if cursorString := r.URL.Query().Get("cursor"); cursorString != "" {
+		cursor, err := decodeCursor(cursorString)
+		if err != nil {
+			return params, errors.New("Cursor is not in correct format")
+		}
+		params.Cursor = &cursor
+	}
+	return params, nil
+}
+
+// cursorArgs returns the cursor as the nullable query arguments sqlc expects.
+func (p pageParams) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true},
+		uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+func (p pageParams) rankArg() sql.NullFloat64 {
+	if p.Cursor == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(p.Cursor.Rank), Valid: true}
+}
+
+// trimChirpPage drops the extra row fetched to detect a following page and
+// returns the cursor pointing past the last chirp that was kept.
+func trimChirpPage(data []database.Chirp, limit int32) ([]database.Chirp, string) {
+	if len(data) <= int(limit) {
+		return data, ""
+	}
+	data = data[:limit]
+	last := data[len(data)-1]
+	return data, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+}
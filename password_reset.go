@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/mailer"
+)
+
+const passwordResetTokenTTL = time.Hour
+
+// A user gets at most one reset mail per passwordResetThrottle; requests
+// while a recent token is still unused are dropped. It matches the interval
+// in HasRecentPasswordResetToken.
+const passwordResetThrottle = 5 * time.Minute
+
+// passwordResetSendTimeout bounds storing and mailing a reset token, which
+// happens after the request that asked for it has finished.
+const passwordResetSendTimeout = 30 * time.Second
+
+// handlePasswordResetRequest always answers 202 so callers cannot tell which
+// emails belong to an account.
+func (cfg *apiConfig) handlePasswordResetRequest(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Email string `json:"email"`
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), request.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to request password reset", err)
+		return
+	}
+
+	// The token is made and mailed off the request path, so a registered
+	// email takes no longer to answer than an unknown one.
+	go cfg.sendPasswordReset(user)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset replaces the user's unused reset tokens with a new one
+// and mails it, unless one was issued within passwordResetThrottle. It runs
+// after the request has been answered, so failures are only logged.
+func (cfg *apiConfig) sendPasswordReset(user database.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+	defer cancel()
+
+	token, err := cfg.issuePasswordResetToken(ctx, user)
+	if err != nil {
+		log.Printf("Unable to create reset token: %s", err)
+		return
+	}
+	if token == "" {
+		return
+	}
+
+	err = cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"Your reset token is: %s\n\n"+
+			"It expires in %s. If this wasn't you, you can ignore this email.\n",
+			token, passwordResetTokenTTL),
+	})
+	if err != nil {
+		log.Printf("Unable to send password reset email: %s", err)
+	}
+}
+
+// issuePasswordResetToken returns "" without issuing a token when the user
+// already has a recent unused one.
+func (cfg *apiConfig) issuePasswordResetToken(ctx context.Context, user database.User) (string, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	recent, err := qtx.HasRecentPasswordResetToken(ctx, user.ID)
+	if err != nil || recent {
+		return "", err
+	}
+	if err := qtx.DeleteUnusedPasswordResetTokens(ctx, user.ID); err != nil {
+		return "", err
+	}
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = qtx.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (cfg *apiConfig) handlePasswordResetConfirm(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	if request.Token == "" || request.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Token and password are required", nil)
+		return
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create hash", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to reset password", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	userId, err := qtx.UsePasswordResetToken(r.Context(), auth.HashToken(request.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, "Reset token is invalid or expired", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to reset password", err)
+		return
+	}
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		HashedPassword: hash,
+		ID:             userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to reset password", err)
+		return
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh tokens", err)
+		return
+	}
+	if err := qtx.RevokeAllPersonalAccessTokensForUser(r.Context(), userId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke access tokens", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to reset password", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxTokenNameLength = 100
+	maxTokenLifetime   = 365 * 24 * time.Hour
+)
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+func databasePersonalAccessTokenToPersonalAccessToken(token database.PersonalAccessToken) PersonalAccessToken {
+	res := PersonalAccessToken{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+	}
+	if token.LastUsedAt.Valid {
+		res.LastUsedAt = &token.LastUsedAt.Time
+	}
+	if token.ExpiresAt.Valid {
+		res.ExpiresAt = &token.ExpiresAt.Time
+	}
+	return res
+}
+
+// handleTokensCreate returns the token value once; only its hash is stored.
+func (cfg *apiConfig) handleTokensCreate(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	type resBody struct {
+		PersonalAccessToken
+		Token string `json:"token"`
+	}
+	p := currentUser(r)
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || utf8.RuneCountInString(request.Name) > maxTokenNameLength {
+		respondWithError(w, http.StatusBadRequest, "Name must be 1 to 100 characters", nil)
+		return
+	}
+	if len(request.Scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "At least one scope is required", nil)
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(auth.ValidScopes, scope) {
+			respondWithError(w, http.StatusBadRequest, "Unknown scope: "+scope, nil)
+			return
+		}
+	}
+	slices.Sort(request.Scopes)
+	request.Scopes = slices.Compact(request.Scopes)
+	expiresAt := sql.NullTime{}
+	if request.ExpiresInDays != 0 {
+		lifetime := time.Duration(request.ExpiresInDays) * 24 * time.Hour
+		if request.ExpiresInDays < 0 || lifetime > maxTokenLifetime {
+			respondWithError(w, http.StatusBadRequest, "Expiry must be between 1 and 365 days", nil)
+			return
+		}
+		expiresAt = sql.NullTime{Time: time.Now().UTC().Add(lifetime), Valid: true}
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create token", err)
+		return
+	}
+	created, err := cfg.dbQueries.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    p.UserID,
+		Name:      request.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    request.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create token", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resBody{
+		PersonalAccessToken: databasePersonalAccessTokenToPersonalAccessToken(created),
+		Token:               token,
+	})
+}
+
+func (cfg *apiConfig) handleTokensList(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Tokens []PersonalAccessToken `json:"tokens"`
+	}
+	p := currentUser(r)
+	data, err := cfg.dbQueries.ListPersonalAccessTokens(r.Context(), p.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get tokens", err)
+		return
+	}
+	tokens := make([]PersonalAccessToken, 0, len(data))
+	for _, token := range data {
+		tokens = append(tokens, databasePersonalAccessTokenToPersonalAccessToken(token))
+	}
+	respondWithJSON(w, http.StatusOK, resBody{Tokens: tokens})
+}
+
+func (cfg *apiConfig) handleTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	p := currentUser(r)
+	tokenId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	revoked, err := cfg.dbQueries.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenId,
+		UserID: p.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke token", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Token not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
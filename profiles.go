@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// PublicProfile is what anyone can see about a user. It never includes the
+// email address.
+type PublicProfile struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func databaseUserToPublicProfile(user database.User) PublicProfile {
+	return PublicProfile{
+		ID:          user.ID,
+		Username:    user.Username.String,
+		DisplayName: user.DisplayName.String,
+		Bio:         user.Bio.String,
+		AvatarURL:   user.AvatarUrl.String,
+		IsChirpyRed: user.IsChirpyRed,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// profileFields are the editable profile fields of PUT /api/users. A nil
+// field is left unchanged.
+type profileFields struct {
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+func (p profileFields) updateParams(userId uuid.UUID) (database.UpdateUserProfileParams, error) {
+	params := database.UpdateUserProfileParams{ID: userId}
+	if p.Username != nil {
+		if !entities.ValidUsername(*p.Username) {
+			return params, errors.New("Username must be 3 to 30 letters, digits or underscores")
+		}
+		params.Username = sql.NullString{String: *p.Username, Valid: true}
+	}
+	if p.DisplayName != nil {
+		if utf8.RuneCountInString(*p.DisplayName) > maxDisplayNameLength {
+			return params, errors.New("Display name is too long")
+		}
+		params.DisplayName = sql.NullString{String: *p.DisplayName, Valid: true}
+	}
+	if p.Bio != nil {
+		if utf8.RuneCountInString(*p.Bio) > maxBioLength {
+			return params, errors.New("Bio is too long")
+		}
+		params.Bio = sql.NullString{String: *p.Bio, Valid: true}
+	}
+	if p.AvatarURL != nil {
+		if *p.AvatarURL != "" && !validAvatarURL(*p.AvatarURL) {
+			return params, errors.New("Avatar URL must be an http or https URL")
+		}
+		params.AvatarUrl = sql.NullString{String: *p.AvatarURL, Valid: true}
+	}
+	return params, nil
+}
+
+func validAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLength {
+		return false
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func (cfg *apiConfig) handleUserProfile(w http.ResponseWriter, r *http.Request) {
+	parsedId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), parsedId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, databaseUserToPublicProfile(user))
+}
+
+func (cfg *apiConfig) handleUserProfileByUsername(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.dbQueries.GetUserByUsername(r.Context(), r.PathValue("name"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, databaseUserToPublicProfile(user))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handleRechirp creates a chirp with no body of its own that points at the
+// original. Rechirping the same chirp twice returns the existing rechirp.
+func (cfg *apiConfig) handleRechirp(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Chirp
+	}
+	userId := currentUser(r).UserID
+	if !cfg.requireVerifiedEmail(w, r, userId) {
+		return
+	}
+	chirpId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+	original, err := cfg.dbQueries.GetChirpsByID(r.Context(), chirpId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
+	if original.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", nil)
+		return
+	}
+	// Rechirping a rechirp reshares the chirp it points at.
+	rechirpOf := uuid.NullUUID{UUID: original.ID, Valid: true}
+	if original.RechirpOf.Valid {
+		rechirpOf = original.RechirpOf
+	}
+
+	status := http.StatusOK
+	rechirp, err := cfg.dbQueries.GetRechirp(r.Context(), database.GetRechirpParams{
+		UserID:    userId,
+		RechirpOf: rechirpOf,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusCreated
+		rechirp, err = cfg.dbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
+			UserID:    userId,
+			RechirpOf: rechirpOf,
+		})
+	}
+	// A concurrent request for the same rechirp got there first; answer with
+	// the one it created.
+	if isUniqueViolation(err, "chirps_user_id_rechirp_of_idx") {
+		status = http.StatusOK
+		rechirp, err = cfg.dbQueries.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:    userId,
+			RechirpOf: rechirpOf,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to rechirp", err)
+		return
+	}
+
+	res, err := cfg.renderChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), rechirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get chirp", err)
+		return
+	}
+	respondWithJSON(w, status, resBody{
+		Chirp: res,
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxUserAgentLength   = 512
+	maxDeviceLabelLength = 100
+)
+
+// Session is one logged-in device, i.e. one refresh token family.
+type Session struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	DeviceLabel string    `json:"device_label,omitempty"`
+}
+
+func databaseSessionToSession(session database.Session) Session {
+	return Session{
+		ID:          session.ID,
+		CreatedAt:   session.CreatedAt,
+		LastUsedAt:  session.LastUsedAt,
+		UserAgent:   session.UserAgent,
+		IP:          session.Ip,
+		DeviceLabel: session.DeviceLabel,
+	}
+}
+
+// clientIP is the address of the peer that opened the connection. Forwarding
+// headers are ignored because they can be set by anyone.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// truncate shortens s to at most max runes.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+func (cfg *apiConfig) handleSessionsList(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Sessions []Session `json:"sessions"`
+	}
+	userId := currentUser(r).UserID
+
+	data, err := cfg.dbQueries.ListActiveSessions(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get sessions", err)
+		return
+	}
+	sessions := make([]Session, 0, len(data))
+	for _, session := range data {
+		sessions = append(sessions, databaseSessionToSession(session))
+	}
+	respondWithJSON(w, http.StatusOK, resBody{Sessions: sessions})
+}
+
+func (cfg *apiConfig) handleSessionRevoke(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+	sessionId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Id is not in correct format", err)
+		return
+	}
+
+	revoked, err := cfg.dbQueries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionId,
+		UserID:   userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke session", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSessionsRevokeAll logs the user out of every session. Personal
+// access tokens are not sessions and keep working; they are revoked through
+// DELETE /api/tokens/{id}.
+func (cfg *apiConfig) handleSessionsRevokeAll(w http.ResponseWriter, r *http.Request) {
+	userId := currentUser(r).UserID
+
+	if err := cfg.dbQueries.RevokeAllRefreshTokensForUser(r.Context(), userId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke sessions", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,10 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (id, actor_id, action, target_user_id, details, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW());
+
+-- name: GetAuditLogPage :many
+SELECT * FROM audit_log
+WHERE (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,22 @@
+-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteChirpLike :execrows
+DELETE FROM chirp_likes
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: GetChirpLikes :many
+SELECT user_id, created_at FROM chirp_likes
+WHERE chirp_id = sqlc.arg(chirp_id)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, user_id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
\ No newline at end of file
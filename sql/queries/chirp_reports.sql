@@ -0,0 +1,45 @@
+-- name: CreateChirpReport :one
+INSERT INTO chirp_reports (id, chirp_id, author_id, reporter_id, category, details, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW()
+)
+RETURNING *;
+
+-- name: CreateContentFilterReport :exec
+INSERT INTO chirp_reports (id, chirp_id, author_id, category, details, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NOW()
+)
+ON CONFLICT (chirp_id) WHERE category = 'content_filter' AND resolved_at IS NULL DO NOTHING;
+
+-- name: GetChirpReportForUpdate :one
+SELECT * FROM chirp_reports
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetOpenChirpReportsPage :many
+SELECT * FROM chirp_reports
+WHERE resolved_at IS NULL
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ResolveChirpReports :execrows
+UPDATE chirp_reports
+SET resolved_at = NOW(),
+resolved_by = sqlc.arg(resolved_by),
+resolution = sqlc.arg(resolution)
+WHERE resolved_at IS NULL
+AND (id = sqlc.arg(id) OR chirp_id = sqlc.narg(chirp_id));
@@ -1,23 +1,112 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, rechirp_of, quote_of)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
--- name: GetAllChirps :many
+-- name: GetChirpsPageAsc :many
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+AND (hidden_at IS NULL OR sqlc.arg(include_hidden)::boolean OR user_id = sqlc.narg(viewer_id))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetChirpsPageDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+AND (hidden_at IS NULL OR sqlc.arg(include_hidden)::boolean OR user_id = sqlc.narg(viewer_id))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetTimelineChirps :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.hidden_at IS NULL
+AND follows.follower_id = sqlc.arg(user_id)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
 
 -- name: GetChirpsByID :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetRechirp :one
+SELECT * FROM chirps
+WHERE user_id = $1
+AND rechirp_of = $2;
+
+-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetChirpByIDForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirp :one
+UPDATE chirps
+SET body = $1,
+updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: GetChirpThread :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.id, chirps.in_reply_to
+    FROM chirps
+    WHERE chirps.id = $1
+    UNION ALL
+    SELECT parent.id, parent.in_reply_to
+    FROM chirps parent
+    JOIN ancestors ON parent.id = ancestors.in_reply_to
+), thread AS (
+    SELECT ancestors.id, 0 AS depth
+    FROM ancestors
+    WHERE ancestors.in_reply_to IS NULL
+    UNION ALL
+    SELECT reply.id, thread.depth + 1
+    FROM chirps reply
+    JOIN thread ON reply.in_reply_to = thread.id
+)
+SELECT sqlc.embed(chirps), thread.depth
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.depth, chirps.created_at, chirps.id;
+
 -- name: DeleteChirpsByID :exec
 DELETE FROM chirps
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ts_rank(search_vector, websearch_to_tsquery('english', sqlc.arg(query)::text)) AS rank
+FROM chirps
+WHERE chirps.hidden_at IS NULL
+AND search_vector @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+AND (sqlc.narg(cursor_rank)::real IS NULL
+    OR (ts_rank(search_vector, websearch_to_tsquery('english', sqlc.arg(query)::text)), id)
+        < (sqlc.narg(cursor_rank)::real, sqlc.narg(cursor_id)::uuid))
+ORDER BY rank DESC, id DESC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
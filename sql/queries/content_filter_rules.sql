@@ -0,0 +1,32 @@
+-- name: CreateContentFilterRule :one
+INSERT INTO content_filter_rules (id, kind, pattern, action, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: DeleteContentFilterRule :one
+DELETE FROM content_filter_rules
+WHERE id = $1
+RETURNING *;
+
+-- name: GetContentFilterRule :one
+SELECT * FROM content_filter_rules
+WHERE id = $1;
+
+-- name: ListContentFilterRules :many
+SELECT * FROM content_filter_rules
+ORDER BY created_at, id;
+
+-- name: UpdateContentFilterRule :one
+UPDATE content_filter_rules
+SET pattern = $1,
+action = $2,
+updated_at = NOW()
+WHERE id = $3
+RETURNING *;
@@ -0,0 +1,27 @@
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE scope = $1
+AND key = $2;
+
+-- name: GetLoginLockoutSeconds :one
+SELECT COALESCE(CEIL(EXTRACT(EPOCH FROM MAX(locked_until) - NOW())), 0)::bigint
+FROM login_failures
+WHERE ((scope = 'email' AND key = sqlc.arg(email)) OR (scope = 'ip' AND key = sqlc.arg(ip)))
+AND locked_until > NOW();
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, key, failures, last_failed_at)
+VALUES ($1, $2, 1, NOW())
+ON CONFLICT (scope, key) DO UPDATE
+SET failures = CASE
+    WHEN login_failures.last_failed_at < NOW() - INTERVAL '1 day' THEN 1
+    ELSE login_failures.failures + 1
+END,
+last_failed_at = NOW()
+RETURNING failures;
+
+-- name: SetLoginLockout :exec
+UPDATE login_failures
+SET locked_until = NOW() + sqlc.arg(lockout_seconds)::float8 * INTERVAL '1 second'
+WHERE scope = sqlc.arg(scope)
+AND key = sqlc.arg(key);
@@ -0,0 +1,25 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES ($1, $2, NOW(), $3);
+
+-- name: DeleteUnusedPasswordResetTokens :exec
+DELETE FROM password_reset_tokens
+WHERE user_id = $1
+AND used_at IS NULL;
+
+-- name: HasRecentPasswordResetToken :one
+SELECT EXISTS (
+    SELECT 1 FROM password_reset_tokens
+    WHERE user_id = $1
+    AND used_at IS NULL
+    AND expires_at > NOW()
+    AND created_at > NOW() - INTERVAL '5 minutes'
+);
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING user_id;
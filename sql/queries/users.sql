@@ -1,28 +1,116 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
 -- name: DeleteALLUser :exec
 DELETE FROM users;
 
+-- name: EnableUserTOTP :execrows
+UPDATE users
+SET totp_enabled_at = NOW(),
+totp_last_step = sqlc.arg(step)::bigint,
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND totp_secret IS NOT NULL
+AND totp_enabled_at IS NULL;
+
 -- name: GetUser :one
 SELECT * FROM users
 WHERE email = $1;
 
--- name: UpdateUser :one
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users
+WHERE LOWER(username) = LOWER(sqlc.arg(username));
+
+-- name: GetUsersByUsernames :many
+SELECT id, username FROM users
+WHERE LOWER(username) = ANY(sqlc.arg(usernames)::text[]);
+
+-- name: GetUsersPage :many
+SELECT * FROM users
+WHERE (sqlc.narg(search)::text IS NULL
+    OR email ILIKE '%' || sqlc.narg(search)::text || '%'
+    OR username ILIKE '%' || sqlc.narg(search)::text || '%')
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: SetUserEmailVerified :one
+UPDATE users
+SET email = $1,
+email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SetUserTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $1,
+updated_at = NOW()
+WHERE id = $2
+AND totp_enabled_at IS NULL;
+
+-- name: SuspendUser :one
 UPDATE users
-SET hashed_password = $1
-WHERE email = $2
+SET suspended_at = NOW(),
+suspended_until = $1,
+suspension_reason = $2,
+updated_at = NOW()
+WHERE id = $3
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_at = NULL,
+suspended_until = NULL,
+suspension_reason = NULL,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $1,
+updated_at = NOW()
+WHERE id = $2;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET username = COALESCE(sqlc.narg(username), username),
+display_name = COALESCE(sqlc.narg(display_name), display_name),
+bio = COALESCE(sqlc.narg(bio), bio),
+avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $1,
+updated_at = NOW()
+WHERE id = $2
 RETURNING *;
 
 -- name: UpdateUserToChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = $1
-WHERE id = $2;
\ No newline at end of file
+WHERE id = $2;
+
+-- name: UseUserTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg(step)::bigint
+WHERE id = sqlc.arg(id)
+AND (totp_last_step IS NULL OR totp_last_step < sqlc.arg(step)::bigint);
\ No newline at end of file
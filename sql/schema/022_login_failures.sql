@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE login_failures (
+    scope TEXT NOT NULL CHECK (scope IN ('email', 'ip')),
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+-- +goose Down
+DROP TABLE login_failures;
@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+-- chirp_id is cleared when the chirp is deleted; author_id keeps the report
+-- actionable afterwards.
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    category TEXT NOT NULL
+        CHECK (category IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolution TEXT
+        CHECK (resolution IN ('dismiss', 'hide_chirp', 'delete_chirp', 'suspend_author'))
+);
+
+CREATE UNIQUE INDEX chirp_reports_open_reporter_idx ON chirp_reports (chirp_id, reporter_id)
+WHERE resolved_at IS NULL;
+CREATE INDEX chirp_reports_open_queue_idx ON chirp_reports (created_at, id)
+WHERE resolved_at IS NULL;
+
+-- +goose Down
+DROP TABLE chirp_reports;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
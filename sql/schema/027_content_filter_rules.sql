@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE content_filter_rules (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('word', 'regex')),
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, pattern)
+);
+
+-- The words the filter used to hardcode.
+INSERT INTO content_filter_rules (id, kind, pattern, action)
+VALUES
+    (gen_random_uuid(), 'word', 'kerfuffle', 'mask'),
+    (gen_random_uuid(), 'word', 'sharbert', 'mask'),
+    (gen_random_uuid(), 'word', 'fornax', 'mask');
+
+-- Chirps a flag rule matched are reported by nobody, in their own category.
+ALTER TABLE chirp_reports
+DROP CONSTRAINT chirp_reports_category_check,
+ADD CONSTRAINT chirp_reports_category_check
+    CHECK (category IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'misinformation', 'other', 'content_filter'));
+
+-- +goose Down
+DELETE FROM chirp_reports
+WHERE category = 'content_filter';
+
+ALTER TABLE chirp_reports
+DROP CONSTRAINT chirp_reports_category_check,
+ADD CONSTRAINT chirp_reports_category_check
+    CHECK (category IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'misinformation', 'other'));
+
+DROP TABLE content_filter_rules;
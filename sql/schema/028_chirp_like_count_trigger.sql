@@ -0,0 +1,27 @@
+-- +goose Up
+-- like_count is kept by triggers so likes removed by a cascade, such as a
+-- user being deleted, are counted too.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_likes_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSE
+        UPDATE chirps SET like_count = GREATEST(like_count - 1, 0) WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirp_likes_count_trigger
+AFTER INSERT OR DELETE ON chirp_likes
+FOR EACH ROW EXECUTE FUNCTION chirp_likes_count();
+
+-- Counts that already drifted are recomputed once.
+UPDATE chirps
+SET like_count = (SELECT COUNT(*) FROM chirp_likes WHERE chirp_likes.chirp_id = chirps.id);
+
+-- +goose Down
+DROP TRIGGER chirp_likes_count_trigger ON chirp_likes;
+DROP FUNCTION chirp_likes_count();
@@ -0,0 +1,21 @@
+-- +goose Up
+-- quote_of is only ever cleared by ON DELETE SET NULL, so a quote losing it
+-- means the quoted chirp was deleted, however that happened.
+-- +goose StatementBegin
+CREATE FUNCTION chirps_mark_quote_deleted() RETURNS trigger AS $$
+BEGIN
+    IF OLD.quote_of IS NOT NULL AND NEW.quote_of IS NULL THEN
+        NEW.quote_deleted := true;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_mark_quote_deleted_trigger
+BEFORE UPDATE OF quote_of ON chirps
+FOR EACH ROW EXECUTE FUNCTION chirps_mark_quote_deleted();
+
+-- +goose Down
+DROP TRIGGER chirps_mark_quote_deleted_trigger ON chirps;
+DROP FUNCTION chirps_mark_quote_deleted();
@@ -0,0 +1,28 @@
+-- +goose Up
+-- reply_count is kept by triggers so replies removed by a cascade, or
+-- detached when their parent is deleted, are counted too.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_replies_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.in_reply_to IS NOT NULL THEN
+        UPDATE chirps SET reply_count = GREATEST(reply_count - 1, 0) WHERE id = OLD.in_reply_to;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.in_reply_to IS NOT NULL THEN
+        UPDATE chirps SET reply_count = reply_count + 1 WHERE id = NEW.in_reply_to;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirp_replies_count_trigger
+AFTER INSERT OR DELETE OR UPDATE OF in_reply_to ON chirps
+FOR EACH ROW EXECUTE FUNCTION chirp_replies_count();
+
+-- Counts that already drifted are recomputed once.
+UPDATE chirps
+SET reply_count = (SELECT COUNT(*) FROM chirps replies WHERE replies.in_reply_to = chirps.id);
+
+-- +goose Down
+DROP TRIGGER chirp_replies_count_trigger ON chirps;
+DROP FUNCTION chirp_replies_count();
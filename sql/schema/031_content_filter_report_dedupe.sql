@@ -0,0 +1,17 @@
+-- +goose Up
+-- Content filter reports have no reporter, so chirp_reports_open_reporter_idx
+-- never matches them. Keep the oldest open one per chirp and allow no more.
+DELETE FROM chirp_reports newer
+USING chirp_reports older
+WHERE newer.category = 'content_filter'
+AND older.category = 'content_filter'
+AND newer.resolved_at IS NULL
+AND older.resolved_at IS NULL
+AND newer.chirp_id = older.chirp_id
+AND (newer.created_at, newer.id) > (older.created_at, older.id);
+
+CREATE UNIQUE INDEX chirp_reports_open_content_filter_idx ON chirp_reports (chirp_id)
+WHERE category = 'content_filter' AND resolved_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirp_reports_open_content_filter_idx;
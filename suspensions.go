@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxSuspensionReasonLength bounds the reason a moderator gives.
+const maxSuspensionReasonLength = 500
+
+var errAccountSuspended = errors.New("account is suspended")
+
+// Suspension describes a user's current suspension to moderators.
+type Suspension struct {
+	SuspendedAt time.Time  `json:"suspended_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Reason      string     `json:"reason"`
+}
+
+// isSuspended reports whether user is suspended now. A suspension without an
+// end lasts until it is lifted.
+func isSuspended(user database.User) bool {
+	if !user.SuspendedAt.Valid {
+		return false
+	}
+	return !user.SuspendedUntil.Valid || user.SuspendedUntil.Time.After(time.Now().UTC())
+}
+
+func userSuspension(user database.User) *Suspension {
+	if !isSuspended(user) {
+		return nil
+	}
+	s := &Suspension{
+		SuspendedAt: user.SuspendedAt.Time,
+		Reason:      user.SuspensionReason.String,
+	}
+	if user.SuspendedUntil.Valid {
+		s.ExpiresAt = &user.SuspendedUntil.Time
+	}
+	return s
+}
+
+// parseSuspension checks the reason and optional end a moderator gave for a
+// suspension. The error is meant for the moderator.
+func parseSuspension(reason string, expiresAt *time.Time) (string, sql.NullTime, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", sql.NullTime{}, errors.New("A reason is required")
+	}
+	if len(reason) > maxSuspensionReasonLength {
+		return "", sql.NullTime{}, errors.New("Reason is too long")
+	}
+	if expiresAt == nil {
+		return reason, sql.NullTime{}, nil
+	}
+	if !expiresAt.After(time.Now()) {
+		return "", sql.NullTime{}, errors.New("expires_at must be in the future")
+	}
+	return reason, sql.NullTime{Time: expiresAt.UTC(), Valid: true}, nil
+}
+
+// suspendUser suspends target, ends its sessions and records who did it.
+// Sessions are ended so lifting the suspension means logging in again.
+func suspendUser(ctx context.Context, qtx *database.Queries, caller auth.Principal, target database.User, reason string, expiresAt sql.NullTime) (database.User, error) {
+	type auditDetails struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	updated, err := qtx.SuspendUser(ctx, database.SuspendUserParams{
+		SuspendedUntil:   expiresAt,
+		SuspensionReason: sql.NullString{String: reason, Valid: true},
+		ID:               target.ID,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(ctx, target.ID); err != nil {
+		return database.User{}, err
+	}
+	details := auditDetails{Reason: reason}
+	if expiresAt.Valid {
+		details.ExpiresAt = &expiresAt.Time
+	}
+	err = recordAudit(ctx, qtx,
+		uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		uuid.NullUUID{UUID: target.ID, Valid: true},
+		auditUserSuspended, details)
+	if err != nil {
+		return database.User{}, err
+	}
+	return updated, nil
+}
+
+// refuseSuspended responds with 403 and returns true when user is suspended.
+func refuseSuspended(w http.ResponseWriter, user database.User) bool {
+	if !isSuspended(user) {
+		return false
+	}
+	msg := "Account is suspended"
+	if user.SuspendedUntil.Valid {
+		msg += " until " + user.SuspendedUntil.Time.Format(time.RFC3339)
+	}
+	respondWithError(w, http.StatusForbidden, msg, errAccountSuspended)
+	return true
+}
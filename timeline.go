@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handleTimeline returns the newest chirps from the accounts the caller follows.
+func (cfg *apiConfig) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+	userId := currentUser(r).UserID
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cursorCreatedAt, cursorId := page.cursorArgs()
+	data, err := cfg.dbQueries.GetTimelineChirps(r.Context(), database.GetTimelineChirpsParams{
+		UserID:          userId,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorId,
+		PageLimit:       page.Limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get timeline", err)
+		return
+	}
+	data, nextCursor := trimChirpPage(data, page.Limit)
+	chirps, err := cfg.renderChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, canSeeHiddenChirps(r), data)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to get timeline", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resBody{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
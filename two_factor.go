@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MaazU-Dev/chirpy/internal/auth"
+	"github.com/MaazU-Dev/chirpy/internal/database"
+)
+
+const (
+	twoFactorIssuer       = "Chirpy"
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+)
+
+// handleTwoFactorSetup starts enrollment by storing a fresh secret. It only
+// takes effect after handleTwoFactorConfirm sees a valid code for it.
+func (cfg *apiConfig) handleTwoFactorSetup(w http.ResponseWriter, r *http.Request) {
+	type resBody struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+	userId := currentUser(r).UserID
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create secret", err)
+		return
+	}
+	updated, err := cfg.dbQueries.SetUserTOTPSecret(r.Context(), database.SetUserTOTPSecretParams{
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+		ID:         userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to start two-factor setup", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(twoFactorIssuer, user.Email, secret),
+	})
+}
+
+// handleTwoFactorConfirm enables two-factor login once the user proves their
+// authenticator works, and returns the recovery codes. They are only shown
+// this once.
+func (cfg *apiConfig) handleTwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		Code string `json:"code"`
+	}
+	type resBody struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	userId := currentUser(r).UserID
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "Start two-factor setup first", nil)
+		return
+	}
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, request.Code, time.Now())
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Code is not valid", nil)
+		return
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create recovery codes", err)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to enable two-factor authentication", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	enabled, err := qtx.EnableUserTOTP(r.Context(), database.EnableUserTOTPParams{
+		Step: step,
+		ID:   userId,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to enable two-factor authentication", err)
+		return
+	}
+	if enabled == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if err := qtx.DeleteTOTPRecoveryCodes(r.Context(), userId); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create recovery codes", err)
+		return
+	}
+	for _, code := range codes {
+		err := qtx.CreateTOTPRecoveryCode(r.Context(), database.CreateTOTPRecoveryCodeParams{
+			UserID:   userId,
+			CodeHash: auth.HashToken(code),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to create recovery codes", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to enable two-factor authentication", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody{RecoveryCodes: codes})
+}
+
+// handleLoginTwoFactor finishes a login that handleLogin answered with a
+// challenge token, using either a TOTP code or an unused recovery code.
+func (cfg *apiConfig) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type reqBody struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+		DeviceLabel    string `json:"device_label"`
+	}
+	var request reqBody
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
+		return
+	}
+	userId, err := auth.ValidateChallengeJWT(request.ChallengeToken, cfg.keyring)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Challenge token is invalid or expired", err)
+		return
+	}
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusBadRequest, "Two-factor authentication is not enabled", nil)
+		return
+	}
+	if !cfg.checkLoginLockout(w, r, user.Email) {
+		return
+	}
+
+	var accepted int64
+	switch {
+	case request.Code != "":
+		step, ok := auth.ValidateTOTP(user.TotpSecret.String, request.Code, time.Now())
+		if ok {
+			// Each time step is accepted once, so an observed code can't be replayed.
+			accepted, err = cfg.dbQueries.UseUserTOTPStep(r.Context(), database.UseUserTOTPStepParams{
+				Step: step,
+				ID:   userId,
+			})
+		}
+	case request.RecoveryCode != "":
+		accepted, err = cfg.dbQueries.UseTOTPRecoveryCode(r.Context(), database.UseTOTPRecoveryCodeParams{
+			UserID:   userId,
+			CodeHash: auth.HashToken(request.RecoveryCode),
+		})
+	default:
+		respondWithError(w, http.StatusBadRequest, "Provide a code or a recovery code", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to check code", err)
+		return
+	}
+	if accepted == 0 {
+		cfg.recordLoginFailure(r.Context(), user.Email, clientIP(r))
+		respondWithError(w, http.StatusUnauthorized, "Code is not valid", nil)
+		return
+	}
+	if refuseSuspended(w, user) {
+		return
+	}
+
+	cfg.completeLogin(w, r, user, request.DeviceLabel)
+}
@@ -1,27 +1,61 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/MaazU-Dev/chirpy/internal/auth"
 	"github.com/MaazU-Dev/chirpy/internal/database"
+	"github.com/MaazU-Dev/chirpy/internal/entities"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	Email       string    `json:"email"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	Username      string    `json:"username,omitempty"`
+	DisplayName   string    `json:"display_name,omitempty"`
+	Bio           string    `json:"bio,omitempty"`
+	AvatarURL     string    `json:"avatar_url,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+}
+
+func databaseUserToUser(user database.User) User {
+	return User{
+		ID:            user.ID,
+		Email:         user.Email,
+		Username:      user.Username.String,
+		DisplayName:   user.DisplayName.String,
+		Bio:           user.Bio.String,
+		AvatarURL:     user.AvatarUrl.String,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		IsChirpyRed:   user.IsChirpyRed,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Role:          user.Role,
+	}
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a write because
+// of the named unique constraint or index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
 }
 
 func (cfg *apiConfig) handleUsersCreate(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Username string `json:"username"`
 	}
 	type resBody struct {
 		User
@@ -32,6 +66,18 @@ func (cfg *apiConfig) handleUsersCreate(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusBadRequest, "Something went wrong", err)
 		return
 	}
+	if !validEmail(request.Email) {
+		respondWithError(w, http.StatusBadRequest, "Email is not a valid address", nil)
+		return
+	}
+	username := sql.NullString{}
+	if request.Username != "" {
+		if !entities.ValidUsername(request.Username) {
+			respondWithError(w, http.StatusBadRequest, "Username must be 3 to 30 letters, digits or underscores", nil)
+			return
+		}
+		username = sql.NullString{String: request.Username, Valid: true}
+	}
 
 	hash, err := auth.HashPassword(request.Password)
 	if err != nil {
@@ -39,35 +85,60 @@ func (cfg *apiConfig) handleUsersCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create User", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	user, err := qtx.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          request.Email,
 		HashedPassword: hash,
+		Username:       username,
 	})
+	if isUniqueViolation(err, "users_username_lower_idx") {
+		respondWithError(w, http.StatusConflict, "Username is already taken", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Unable to create User", err)
 		return
 	}
+	verificationToken, err := issueEmailVerificationToken(r.Context(), qtx, user.ID, user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create verification token", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create User", err)
+		return
+	}
+	cfg.sendEmailVerification(r.Context(), user.Email, verificationToken)
+
 	res := resBody{
-		User: User{
-			ID:          user.ID,
-			Email:       user.Email,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User: databaseUserToUser(user),
 	}
 	respondWithJSON(w, http.StatusCreated, res)
 }
 
+// loginResponse is returned once a login has passed every factor.
+type loginResponse struct {
+	User
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string `json:"email"`
+		Password    string `json:"password"`
+		DeviceLabel string `json:"device_label"`
 	}
-	type resBody struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
+	type challengeResBody struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
 	}
 	var request reqBody
 	decoder := json.NewDecoder(r.Body)
@@ -76,19 +147,57 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !cfg.checkLoginLockout(w, r, request.Email) {
+		return
+	}
+
 	user, err := cfg.dbQueries.GetUser(r.Context(), request.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		if hash, err := dummyPasswordHash(); err == nil {
+			auth.CheckPasswordHash(request.Password, hash)
+		}
+		cfg.recordLoginFailure(r.Context(), request.Email, clientIP(r))
+		respondWithError(w, http.StatusUnauthorized, errLoginFailed, err)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "User does not exists", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to log in", err)
 		return
 	}
 
 	same, err := auth.CheckPasswordHash(request.Password, user.HashedPassword)
 	if err != nil || !same {
-		respondWithError(w, http.StatusUnauthorized, "Password does not match", err)
+		cfg.recordLoginFailure(r.Context(), request.Email, clientIP(r))
+		respondWithError(w, http.StatusUnauthorized, errLoginFailed, err)
+		return
+	}
+	if refuseSuspended(w, user) {
 		return
 	}
 
-	jwt, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	if user.TotpEnabledAt.Valid {
+		challenge, err := auth.MakeChallengeJWT(user.ID, cfg.keyring, twoFactorChallengeTTL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to create challenge token", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, challengeResBody{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		})
+		return
+	}
+
+	cfg.completeLogin(w, r, user, request.DeviceLabel)
+}
+
+// completeLogin starts a session for user and responds with its access and
+// refresh tokens. Failed attempts are only forgiven here, once every factor
+// has passed.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User, deviceLabel string) {
+	cfg.clearLoginFailures(r.Context(), user.Email)
+
+	jwt, err := auth.MakeJWT(user.ID, cfg.keyring, time.Hour)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to create Auth Token", err)
 		return
@@ -100,77 +209,171 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cfg.dbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 30),
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create session", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	session, err := qtx.CreateSession(r.Context(), database.CreateSessionParams{
+		UserID:      user.ID,
+		UserAgent:   truncate(r.UserAgent(), maxUserAgentLength),
+		Ip:          clientIP(r),
+		DeviceLabel: truncate(deviceLabel, maxDeviceLabelLength),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create session", err)
+		return
+	}
+	_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash: auth.HashToken(refreshToken),
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
 		UserID:    user.ID,
+		FamilyID:  session.ID,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to create Refresh Token in db", err)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to create session", err)
+		return
+	}
 
-	res := resBody{
-		User: User{
-			ID:          user.ID,
-			Email:       user.Email,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+	respondWithJSON(w, http.StatusOK, loginResponse{
+		User:         databaseUserToUser(user),
 		Token:        jwt,
 		RefreshToken: refreshToken,
-	}
-	respondWithJSON(w, http.StatusOK, res)
+	})
 }
 
+// handleUsersUpdate serves PUT and PATCH /api/users. Only the fields present
+// in the body are changed, always on the account the JWT belongs to.
+// Changing the password ends every session and revokes every personal access
+// token, since the access token doesn't say which session it came from; the
+// caller logs in again once its access token expires.
 func (cfg *apiConfig) handleUsersUpdate(w http.ResponseWriter, r *http.Request) {
 	type reqBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email           *string `json:"email"`
+		Password        *string `json:"password"`
+		CurrentPassword string  `json:"current_password"`
+		profileFields
 	}
 	type resBody struct {
 		User
+		PendingEmail string `json:"pending_email,omitempty"`
 	}
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "You are not authorized", err)
+	caller := currentUser(r)
+	userId := caller.UserID
+	var request reqBody
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unable to decode request", err)
 		return
 	}
-	_, err = auth.ValidateJWT(token, cfg.jwtSecret)
+	if caller.IsPersonalToken() && (request.Email != nil || request.Password != nil) {
+		respondWithError(w, http.StatusForbidden, "Personal access tokens cannot change the email or password", nil)
+		return
+	}
+	profileParams, err := request.profileFields.updateParams(userId)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "You are not authorized", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	/// Fetch the user record(Combinde both queries and use a transaction, SHOULD WE?) from the user ID received from the JWT
-	// Run validation on email and password
-	// Email should be same, password should not be same
-	var request reqBody
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&request); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Provide email or password", err)
+	if request.Password != nil && *request.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Password cannot be empty", nil)
 		return
 	}
-	hash, err := auth.HashPassword(request.Password)
+	if request.Email != nil && !validEmail(*request.Email) {
+		respondWithError(w, http.StatusBadRequest, "Email is not a valid address", nil)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to create hash", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to update user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	user, err := qtx.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return
+	}
+
+	if request.Password != nil {
+		match, err := auth.CheckPasswordHash(request.CurrentPassword, user.HashedPassword)
+		if err != nil || !match {
+			respondWithError(w, http.StatusUnauthorized, "Current password is incorrect", err)
+			return
+		}
+		hash, err := auth.HashPassword(*request.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to create hash", err)
+			return
+		}
+		err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hash,
+			ID:             userId,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to update user", err)
+			return
+		}
+		if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userId); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh tokens", err)
+			return
+		}
+		if err := qtx.RevokeAllPersonalAccessTokensForUser(r.Context(), userId); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to revoke access tokens", err)
+			return
+		}
+	}
+
+	updatedUser, err := qtx.UpdateUserProfile(r.Context(), profileParams)
+	if isUniqueViolation(err, "users_username_lower_idx") {
+		respondWithError(w, http.StatusConflict, "Username is already taken", err)
 		return
 	}
-	updatedUser, err := cfg.dbQueries.UpdateUser(r.Context(), database.UpdateUserParams{
-		Email:          request.Email,
-		HashedPassword: hash,
-	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to update user", err)
 		return
 	}
+
+	// A new email only replaces the current one once it has been verified.
+	pendingEmail, verificationToken := "", ""
+	if request.Email != nil && *request.Email != user.Email {
+		_, err := qtx.GetUser(r.Context(), *request.Email)
+		if err == nil {
+			respondWithError(w, http.StatusConflict, "Email is already in use", nil)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Unable to update user", err)
+			return
+		}
+		verificationToken, err = issueEmailVerificationToken(r.Context(), qtx, userId, *request.Email)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Unable to create verification token", err)
+			return
+		}
+		pendingEmail = *request.Email
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Unable to update user", err)
+		return
+	}
+	if pendingEmail != "" {
+		cfg.sendEmailVerification(r.Context(), pendingEmail, verificationToken)
+	}
+
 	respondWithJSON(w, http.StatusOK, resBody{
-		User: User{
-			ID:          updatedUser.ID,
-			Email:       updatedUser.Email,
-			CreatedAt:   updatedUser.CreatedAt,
-			UpdatedAt:   updatedUser.UpdatedAt,
-			IsChirpyRed: updatedUser.IsChirpyRed,
-		},
+		User:         databaseUserToUser(updatedUser),
+		PendingEmail: pendingEmail,
 	})
 }